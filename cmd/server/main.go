@@ -13,11 +13,20 @@ import (
 	"time"
 
 	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/accesslog"
+	"go-cloud-camp-2025-test-assignment/internal/admin"
 	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/discovery"
 	"go-cloud-camp-2025-test-assignment/internal/health"
+	"go-cloud-camp-2025-test-assignment/internal/identity"
+	"go-cloud-camp-2025-test-assignment/internal/latency"
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
 	"go-cloud-camp-2025-test-assignment/internal/proxy"
 	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
+	"go-cloud-camp-2025-test-assignment/internal/reload"
+	"go-cloud-camp-2025-test-assignment/internal/requestid"
 	"go-cloud-camp-2025-test-assignment/internal/storage"
+	"go-cloud-camp-2025-test-assignment/internal/tracing"
 	"go-cloud-camp-2025-test-assignment/pkg/logger"
 	"go-cloud-camp-2025-test-assignment/pkg/redis"
 
@@ -35,20 +44,39 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger.Setup(cfg.Logging)
+	if err := logger.Setup(cfg.Logging); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go handleSignals(cancel)
 
-	loadBalancer, err := balancer.BalancerFactory(cfg)
+	backendState, err := admin.NewStateStore(cfg.Admin.StateFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load backend state")
+	}
+
+	initialBalancer, err := balancer.BalancerFactory(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create load balancer")
 	}
+	loadBalancer := balancer.NewDynamicBalancer(initialBalancer)
 
-	var rateLimiter ratelimit.RateLimiter
+	backendPool := discovery.NewManager(loadBalancer, backendState, cfg.Backends)
+	backendPool.Sync()
+
+	for _, provider := range discovery.NewProviders(cfg.Discovery) {
+		backendPool.Start(ctx, provider)
+	}
+
+	var rateLimiter ratelimit.ClientRateLimiter
+	var concurrencyLimiter *ratelimit.ConcurrencyLimiter
 	var clientManager *ratelimit.ClientManager
+	var identityResolver *identity.Resolver
 
 	if cfg.RateLimit.Enabled {
 
@@ -72,27 +100,66 @@ func main() {
 
 		defer store.Close()
 
-		tbRateLimiter, err := ratelimit.NewTokenBucketRateLimiter(store, &cfg.RateLimit)
+		rateLimiter, err = ratelimit.NewRateLimiter(store, &cfg.RateLimit)
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to create rate limiter")
 		}
-		defer tbRateLimiter.Close()
+		defer rateLimiter.Close()
+
+		identityResolver, err = identity.NewResolver(cfg.RateLimit.Identity, cfg.RateLimit.TrustedProxies)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create client identity resolver")
+		}
 
-		rateLimiter = tbRateLimiter
-		clientManager = ratelimit.NewClientManager(store, tbRateLimiter, &cfg.RateLimit)
+		concurrencyLimiter = ratelimit.NewConcurrencyLimiter(store, &cfg.RateLimit)
+		clientManager = ratelimit.NewClientManager(store, rateLimiter, concurrencyLimiter, identityResolver)
 	}
 
-	healthChecker := health.NewHTTPHealthChecker(&cfg.HealthCheck)
+	healthMonitor := health.NewMonitor(ctx, loadBalancer)
+	healthMonitor.Start(cfg.HealthCheck)
+
+	reloader := reload.NewReloader(*configPath, cfg, backendPool, healthMonitor, rateLimiter, concurrencyLimiter)
+	go handleReloadSignal(ctx, reloader)
 
-	if cfg.HealthCheck.Enabled {
-		go balancer.StartHealthChecks(ctx, loadBalancer, &cfg.HealthCheck, healthChecker)
+	if cfg.Reload.Watch {
+		if err := reloader.Watch(ctx, cfg.Reload.Debounce); err != nil {
+			log.Error().Err(err).Msg("Failed to watch config file")
+		}
 	}
 
-	proxyServer := proxy.NewProxy(
-		loadBalancer,
-		cfg,
+	tracer := tracing.NewTracer(cfg.Tracing)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Tracing.Timeout)
+		defer cancel()
+		if err := tracer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Failed to flush traces")
+		}
+	}()
+
+	reopeners := []func() error{logger.Reopen}
+
+	proxyOptions := []proxy.ProxyOption{
 		proxy.WithRateLimiter(rateLimiter),
-	)
+		proxy.WithConcurrencyLimiter(concurrencyLimiter),
+		proxy.WithCostRules(ratelimit.NewCostRules(cfg.RateLimit.Cost)),
+		proxy.WithIdentity(identityResolver),
+		proxy.WithTracer(tracer),
+	}
+
+	if cfg.AccessLog.Enabled {
+		accessLog, err := accesslog.New(cfg.AccessLog)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create access log")
+		}
+		defer accessLog.Close()
+
+		reopeners = append(reopeners, accessLog.Reopen)
+		proxyOptions = append(proxyOptions, proxy.WithAccessLog(accessLog))
+	}
+
+	go handleReopenSignal(ctx, reopeners...)
+
+	proxyServer := proxy.NewProxy(loadBalancer, cfg, proxyOptions...)
 
 	mux := http.NewServeMux()
 
@@ -103,12 +170,27 @@ func main() {
 		mux.HandleFunc("/client-status", clientManager.HandleStatus)
 	}
 
+	backendManager := admin.NewBackendManager(loadBalancer, backendPool, cfg)
+	backendManager.RegisterHandlers(mux)
+	mux.HandleFunc("/admin/log-level", admin.HandleLogLevel)
+
 	mux.HandleFunc("/lb-status", func(w http.ResponseWriter, r *http.Request) {
+		panicStatus := loadBalancer.PanicStatus()
 		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, `{"status":"ok","balancer":"%s","backends":%d}`,
-			loadBalancer.Name(), len(loadBalancer.GetHealthyBackends()))
+		json.NewEncoder(w).Encode(lbStatus{
+			Status:       "ok",
+			Balancer:     loadBalancer.Name(),
+			Backends:     len(loadBalancer.GetHealthyBackends()),
+			PanicMode:    panicStatus.Active,
+			PanicEntered: panicStatus.Entered,
+			PanicExited:  panicStatus.Exited,
+			Latency:      proxyServer.LatencyStats(),
+		})
 	})
 
+	balancer.RegisterMetrics(metrics.Default, loadBalancer)
+	mux.Handle("/metrics", metrics.Handler())
+
 	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
 		stats := loadBalancer.GetStatistics()
 		w.Header().Set("Content-Type", "application/json")
@@ -117,7 +199,7 @@ func main() {
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      mux,
+		Handler:      requestid.Middleware(cfg.RequestID, metrics.Instrument(mux)),
 		ReadTimeout:  cfg.Server.Timeout,
 		WriteTimeout: cfg.Server.Timeout,
 		IdleTimeout:  120 * time.Second,
@@ -143,6 +225,54 @@ func main() {
 	log.Info().Msg("Server gracefully stopped")
 }
 
+func handleReloadSignal(ctx context.Context, reloader *reload.Reloader) {
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGHUP)
+	defer signal.Stop(signalChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signalChan:
+			log.Info().Msg("Received SIGHUP, reloading configuration")
+			reloader.Reload()
+		}
+	}
+}
+
+// handleReopenSignal по SIGUSR1 заново открывает файлы журналов, чтобы
+// внешний logrotate мог переименовать их без остановки сервера.
+func handleReopenSignal(ctx context.Context, reopeners ...func() error) {
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGUSR1)
+	defer signal.Stop(signalChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signalChan:
+			log.Info().Msg("Received SIGUSR1, reopening log files")
+			for _, reopen := range reopeners {
+				if err := reopen(); err != nil {
+					log.Error().Err(err).Msg("Failed to reopen log file")
+				}
+			}
+		}
+	}
+}
+
+type lbStatus struct {
+	Status       string        `json:"status"`
+	Balancer     string        `json:"balancer"`
+	Backends     int           `json:"backends"`
+	PanicMode    bool          `json:"panic_mode"`
+	PanicEntered int64         `json:"panic_entered_total"`
+	PanicExited  int64         `json:"panic_exited_total"`
+	Latency      latency.Stats `json:"latency"`
+}
+
 func handleSignals(cancel context.CancelFunc) {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
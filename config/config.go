@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -17,6 +19,13 @@ type Config struct {
 	Balancer    BalancerConfig    `mapstructure:"balancer"`
 	HealthCheck HealthCheckConfig `mapstructure:"health_check"`
 	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
+	Admin       AdminConfig       `mapstructure:"admin"`
+	Reload      ReloadConfig      `mapstructure:"reload"`
+	Discovery   DiscoveryConfig   `mapstructure:"discovery"`
+	Sticky      StickyConfig      `mapstructure:"sticky_session"`
+	Tracing     TracingConfig     `mapstructure:"tracing"`
+	RequestID   RequestIDConfig   `mapstructure:"request_id"`
+	AccessLog   AccessLogConfig   `mapstructure:"access_log"`
 }
 
 type ServerConfig struct {
@@ -25,18 +34,53 @@ type ServerConfig struct {
 }
 
 type LoggerConfig struct {
-	Level    string `mapstructure:"level"`
-	Format   string `mapstructure:"format"`
-	Output   string `mapstructure:"output"`
-	FilePath string `mapstructure:"file_path"`
+	Level    string         `mapstructure:"level"`
+	Format   string         `mapstructure:"format"`
+	Output   string         `mapstructure:"output"`
+	FilePath string         `mapstructure:"file_path"`
+	Rotation RotationConfig `mapstructure:"rotation"`
+}
+
+// RotationConfig задаёт ротацию файла журнала. Нулевые max_size_mb и
+// interval отключают соответствующий триггер, нулевой max_backups хранит все
+// ротированные файлы.
+type RotationConfig struct {
+	MaxSizeMB  int           `mapstructure:"max_size_mb"`
+	Interval   time.Duration `mapstructure:"interval"`
+	MaxBackups int           `mapstructure:"max_backups"`
+	Compress   bool          `mapstructure:"compress"`
+}
+
+type AccessLogConfig struct {
+	Enabled  bool               `mapstructure:"enabled"`
+	Format   string             `mapstructure:"format"`
+	Template string             `mapstructure:"template"`
+	Output   string             `mapstructure:"output"`
+	FilePath string             `mapstructure:"file_path"`
+	Sampling map[string]float64 `mapstructure:"sampling"`
+	Rotation RotationConfig     `mapstructure:"rotation"`
 }
 
 type BackendConfig struct {
-	URL string `mapstructure:"url"`
+	URL      string            `mapstructure:"url"`
+	Weight   int               `mapstructure:"weight"`
+	Priority int               `mapstructure:"priority"`
+	Zone     string            `mapstructure:"zone"`
+	Metadata map[string]string `mapstructure:"metadata"`
 }
 
 type BalancerConfig struct {
-	Algorithm string `mapstructure:"algorithm"`
+	Algorithm              string          `mapstructure:"algorithm"`
+	SlowStart              SlowStartConfig `mapstructure:"slow_start"`
+	OverprovisioningFactor float64         `mapstructure:"overprovisioning_factor"`
+	Zone                   string          `mapstructure:"zone"`
+	PanicThreshold         float64         `mapstructure:"panic_threshold"`
+}
+
+type SlowStartConfig struct {
+	Window    time.Duration `mapstructure:"window"`
+	Mode      string        `mapstructure:"mode"`
+	MinWeight float64       `mapstructure:"min_weight"`
 }
 
 type HealthCheckConfig struct {
@@ -46,9 +90,146 @@ type HealthCheckConfig struct {
 }
 
 type RateLimitConfig struct {
-	Enabled bool              `mapstructure:"enabled"`
-	Redis   RedisConfig       `mapstructure:"redis"`
-	Default TokenBucketConfig `mapstructure:"default"`
+	Enabled        bool                         `mapstructure:"enabled"`
+	Algorithm      string                       `mapstructure:"algorithm"`
+	Window         time.Duration                `mapstructure:"window"`
+	Redis          RedisConfig                  `mapstructure:"redis"`
+	Default        TokenBucketConfig            `mapstructure:"default"`
+	Concurrency    ConcurrencyConfig            `mapstructure:"concurrency"`
+	Global         TokenBucketConfig            `mapstructure:"global"`
+	Routes         []RouteLimitConfig           `mapstructure:"routes"`
+	Cost           CostConfig                   `mapstructure:"cost"`
+	Headers        string                       `mapstructure:"headers"`
+	Identity       []IdentityConfig             `mapstructure:"identity"`
+	Groups         map[string]TokenBucketConfig `mapstructure:"groups"`
+	CIDRs          []CIDRLimitConfig            `mapstructure:"cidrs"`
+	TrustedProxies []string                     `mapstructure:"trusted_proxies"`
+}
+
+// CIDRLimitConfig - лимит клиентов из диапазона адресов. Если адрес входит в
+// несколько диапазонов, применяется самый узкий.
+type CIDRLimitConfig struct {
+	CIDR       string `mapstructure:"cidr"`
+	Capacity   int    `mapstructure:"capacity"`
+	RefillRate int    `mapstructure:"refill_rate"`
+}
+
+// IdentityConfig - способ определить клиента для rate limiting: header или
+// query (значение заголовка или параметра Name), jwt (утверждение Claim
+// токена из заголовка Name, подпись проверяется ключами из JWKSFile), mtls
+// (субъект клиентского сертификата или, за TLS-терминатором, заголовок Name)
+// или ip. Способы пробуются по порядку до первого успешного.
+type IdentityConfig struct {
+	Type     string `mapstructure:"type"`
+	Name     string `mapstructure:"name"`
+	Claim    string `mapstructure:"claim"`
+	JWKSFile string `mapstructure:"jwks_file"`
+}
+
+// RouteLimitConfig - общий для всех клиентов бакет запросов, подходящих под
+// метод и путь. Пустой метод подходит под любой; путь, оканчивающийся на
+// "*", задаёт префикс.
+type RouteLimitConfig struct {
+	Method     string `mapstructure:"method"`
+	Path       string `mapstructure:"path"`
+	Capacity   int    `mapstructure:"capacity"`
+	RefillRate int    `mapstructure:"refill_rate"`
+}
+
+// CostConfig задаёт стоимость запроса в токенах. Стоимость берётся из
+// заголовка Header, если его выставил вышестоящий слой авторизации, иначе из
+// первого подходящего правила, иначе Default.
+type CostConfig struct {
+	Default int              `mapstructure:"default"`
+	Header  string           `mapstructure:"header"`
+	Rules   []CostRuleConfig `mapstructure:"rules"`
+}
+
+// CostRuleConfig подходит под запрос, если совпадают все заданные условия:
+// метод, путь (с "*" в конце - префикс), заявленный Content-Length не меньше
+// MinContentLength и заголовок Header со значением HeaderValue (пустое
+// значение - любое непустое).
+type CostRuleConfig struct {
+	Method           string `mapstructure:"method"`
+	Path             string `mapstructure:"path"`
+	MinContentLength int64  `mapstructure:"min_content_length"`
+	Header           string `mapstructure:"header"`
+	HeaderValue      string `mapstructure:"header_value"`
+	Cost             int    `mapstructure:"cost"`
+}
+
+// ConcurrencyConfig ограничивает число одновременных запросов клиента.
+// MaxInFlight 0 снимает ограничение для клиентов без своего лимита. Слот
+// запроса истекает через LeaseTTL, даже если не был освобождён.
+type ConcurrencyConfig struct {
+	MaxInFlight int           `mapstructure:"max_in_flight"`
+	LeaseTTL    time.Duration `mapstructure:"lease_ttl"`
+}
+
+type StickyConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	CookieName string        `mapstructure:"cookie_name"`
+	TTL        time.Duration `mapstructure:"ttl"`
+	Path       string        `mapstructure:"path"`
+	Secret     string        `mapstructure:"secret"`
+}
+
+type TracingConfig struct {
+	Enabled       bool              `mapstructure:"enabled"`
+	Endpoint      string            `mapstructure:"endpoint"`
+	Headers       map[string]string `mapstructure:"headers"`
+	ServiceName   string            `mapstructure:"service_name"`
+	SampleRatio   float64           `mapstructure:"sample_ratio"`
+	BatchSize     int               `mapstructure:"batch_size"`
+	FlushInterval time.Duration     `mapstructure:"flush_interval"`
+	Timeout       time.Duration     `mapstructure:"timeout"`
+}
+
+type RequestIDConfig struct {
+	Header string `mapstructure:"header"`
+}
+
+type AdminConfig struct {
+	StateFile string `mapstructure:"state_file"`
+}
+
+type ReloadConfig struct {
+	Watch    bool          `mapstructure:"watch"`
+	Debounce time.Duration `mapstructure:"debounce"`
+}
+
+type DiscoveryConfig struct {
+	DNS  []DNSDiscoveryConfig  `mapstructure:"dns"`
+	File []FileDiscoveryConfig `mapstructure:"file"`
+	HTTP []HTTPDiscoveryConfig `mapstructure:"http"`
+}
+
+type DNSDiscoveryConfig struct {
+	Name        string        `mapstructure:"name"`
+	Type        string        `mapstructure:"type"`
+	Port        int           `mapstructure:"port"`
+	Scheme      string        `mapstructure:"scheme"`
+	Weight      int           `mapstructure:"weight"`
+	Priority    int           `mapstructure:"priority"`
+	Zone        string        `mapstructure:"zone"`
+	Interval    time.Duration `mapstructure:"interval"`
+	MinInterval time.Duration `mapstructure:"min_interval"`
+	Nameserver  string        `mapstructure:"nameserver"`
+}
+
+type FileDiscoveryConfig struct {
+	Path     string        `mapstructure:"path"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+type HTTPDiscoveryConfig struct {
+	URL      string        `mapstructure:"url"`
+	Interval time.Duration `mapstructure:"interval"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+func (d DiscoveryConfig) Enabled() bool {
+	return len(d.DNS) > 0 || len(d.File) > 0 || len(d.HTTP) > 0
 }
 
 type RedisConfig struct {
@@ -83,9 +264,10 @@ func LoadConfig(configPath string) (*Config, error) {
 
 	if err := v.ReadInConfig(); err != nil {
 		var configFileNotFoundError viper.ConfigFileNotFoundError
-		if errors.As(err, &configFileNotFoundError) {
-			fmt.Fprintf(os.Stderr, "Warning: No config file found\n")
+		if !errors.As(err, &configFileNotFoundError) {
+			return nil, fmt.Errorf("unable to read config file: %w", err)
 		}
+		fmt.Fprintf(os.Stderr, "Warning: No config file found\n")
 	}
 
 	var config Config
@@ -109,19 +291,67 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
 	v.SetDefault("logging.file_path", "./logs/balancer.log")
+	v.SetDefault("logging.rotation.max_size_mb", 0)
+	v.SetDefault("logging.rotation.interval", 0)
+	v.SetDefault("logging.rotation.max_backups", 0)
+	v.SetDefault("logging.rotation.compress", false)
+
+	v.SetDefault("access_log.enabled", false)
+	v.SetDefault("access_log.format", "json")
+	v.SetDefault("access_log.output", "stdout")
+	v.SetDefault("access_log.file_path", "./logs/access.log")
+	v.SetDefault("access_log.rotation.max_size_mb", 0)
+	v.SetDefault("access_log.rotation.interval", 0)
+	v.SetDefault("access_log.rotation.max_backups", 0)
+	v.SetDefault("access_log.rotation.compress", false)
 
 	v.SetDefault("balancer.algorithm", "round_robin")
+	v.SetDefault("balancer.slow_start.window", "0s")
+	v.SetDefault("balancer.slow_start.mode", "linear")
+	v.SetDefault("balancer.slow_start.min_weight", 0.1)
+	v.SetDefault("balancer.overprovisioning_factor", 1.4)
+	v.SetDefault("balancer.zone", "")
+	v.SetDefault("balancer.panic_threshold", 0)
 
 	v.SetDefault("health_check.enabled", true)
 	v.SetDefault("health_check.interval", "5s")
 	v.SetDefault("health_check.path", "/health")
 
 	v.SetDefault("rate_limit.enabled", true)
+	v.SetDefault("rate_limit.algorithm", "token_bucket")
+	v.SetDefault("rate_limit.window", time.Minute)
 	v.SetDefault("rate_limit.redis.addr", "localhost:6379")
 	v.SetDefault("rate_limit.redis.password", "")
 	v.SetDefault("rate_limit.redis.db", 0)
 	v.SetDefault("rate_limit.default.capacity", 50)
 	v.SetDefault("rate_limit.default.refill_rate", 10)
+	v.SetDefault("rate_limit.concurrency.max_in_flight", 0)
+	v.SetDefault("rate_limit.concurrency.lease_ttl", time.Minute)
+	v.SetDefault("rate_limit.global.capacity", 0)
+	v.SetDefault("rate_limit.global.refill_rate", 0)
+	v.SetDefault("rate_limit.cost.default", 1)
+	v.SetDefault("rate_limit.headers", "ietf")
+
+	v.SetDefault("sticky_session.enabled", false)
+	v.SetDefault("sticky_session.cookie_name", "lb_affinity")
+	v.SetDefault("sticky_session.ttl", "1h")
+	v.SetDefault("sticky_session.path", "/")
+	v.SetDefault("sticky_session.secret", "")
+
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "http://localhost:4318/v1/traces")
+	v.SetDefault("tracing.service_name", "load-balancer")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("tracing.batch_size", 512)
+	v.SetDefault("tracing.flush_interval", "5s")
+	v.SetDefault("tracing.timeout", "10s")
+
+	v.SetDefault("request_id.header", "X-Request-ID")
+
+	v.SetDefault("admin.state_file", "")
+
+	v.SetDefault("reload.watch", false)
+	v.SetDefault("reload.debounce", "1s")
 }
 
 func validateConfig(config *Config) error {
@@ -130,8 +360,12 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("server port must be between 1 and 65535")
 	}
 
-	if len(config.Backends) == 0 {
-		return fmt.Errorf("at least one backend must be configured")
+	if len(config.Backends) == 0 && !config.Discovery.Enabled() {
+		return fmt.Errorf("at least one backend or discovery source must be configured")
+	}
+
+	if err := validateDiscovery(&config.Discovery); err != nil {
+		return err
 	}
 
 	validAlgorithms := map[string]bool{
@@ -143,6 +377,42 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("invalid balancer algorithm: %s", config.Balancer.Algorithm)
 	}
 
+	for _, backend := range config.Backends {
+		if err := validateBackendURL(backend.URL); err != nil {
+			return err
+		}
+		if backend.Weight < 0 {
+			return fmt.Errorf("backend %s: weight must not be negative", backend.URL)
+		}
+		if backend.Priority < 0 {
+			return fmt.Errorf("backend %s: priority must not be negative", backend.URL)
+		}
+	}
+
+	if config.Balancer.OverprovisioningFactor < 1 {
+		return fmt.Errorf("overprovisioning_factor must be at least 1")
+	}
+
+	if config.Balancer.PanicThreshold < 0 || config.Balancer.PanicThreshold > 1 {
+		return fmt.Errorf("panic_threshold must be in range [0, 1]")
+	}
+
+	if config.Balancer.SlowStart.Window < 0 {
+		return fmt.Errorf("slow_start window must not be negative")
+	}
+
+	validSlowStartModes := map[string]bool{
+		"linear":      true,
+		"exponential": true,
+	}
+	if !validSlowStartModes[config.Balancer.SlowStart.Mode] {
+		return fmt.Errorf("invalid slow_start mode: %s", config.Balancer.SlowStart.Mode)
+	}
+
+	if config.Balancer.SlowStart.MinWeight <= 0 || config.Balancer.SlowStart.MinWeight > 1 {
+		return fmt.Errorf("slow_start min_weight must be in range (0, 1]")
+	}
+
 	validLogLevels := map[string]bool{
 		"debug": true,
 		"info":  true,
@@ -173,5 +443,282 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("file_path must be specified when output is set to file")
 	}
 
+	if err := validateRotation("logging", config.Logging.Rotation); err != nil {
+		return err
+	}
+
+	if err := validateRateLimit(&config.RateLimit); err != nil {
+		return err
+	}
+
+	if err := validateAccessLog(&config.AccessLog); err != nil {
+		return err
+	}
+
+	if config.RequestID.Header == "" {
+		return fmt.Errorf("request_id header must not be empty")
+	}
+
+	if config.Sticky.Enabled {
+		if config.Sticky.CookieName == "" {
+			return fmt.Errorf("sticky_session cookie_name must not be empty")
+		}
+		if config.Sticky.Secret == "" {
+			return fmt.Errorf("sticky_session secret must be specified when sticky sessions are enabled")
+		}
+		if config.Sticky.TTL <= 0 {
+			return fmt.Errorf("sticky_session ttl must be positive")
+		}
+	}
+
+	if config.Tracing.Enabled {
+		if config.Tracing.Endpoint == "" {
+			return fmt.Errorf("tracing endpoint must be specified when tracing is enabled")
+		}
+		if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("tracing sample_ratio must be in range [0, 1]")
+		}
+		if config.Tracing.BatchSize <= 0 {
+			return fmt.Errorf("tracing batch_size must be positive")
+		}
+		if config.Tracing.FlushInterval <= 0 || config.Tracing.Timeout <= 0 {
+			return fmt.Errorf("tracing flush_interval and timeout must be positive")
+		}
+	}
+
+	return nil
+}
+
+func validateRateLimit(rateLimit *RateLimitConfig) error {
+	if !rateLimit.Enabled {
+		return nil
+	}
+
+	validAlgorithms := map[string]bool{
+		"token_bucket":   true,
+		"sliding_window": true,
+		"gcra":           true,
+	}
+	if !validAlgorithms[rateLimit.Algorithm] {
+		return fmt.Errorf("invalid rate_limit algorithm: %s", rateLimit.Algorithm)
+	}
+	if rateLimit.Algorithm == "sliding_window" && rateLimit.Window <= 0 {
+		return fmt.Errorf("rate_limit window must be positive for sliding_window algorithm")
+	}
+	layered := rateLimit.Global.Capacity > 0 || len(rateLimit.Routes) > 0
+	if layered && rateLimit.Algorithm != "token_bucket" {
+		return fmt.Errorf("rate_limit global and routes limits require token_bucket algorithm")
+	}
+	if rateLimit.Global.Capacity < 0 || (rateLimit.Global.Capacity > 0 && rateLimit.Global.RefillRate <= 0) {
+		return fmt.Errorf("rate_limit global capacity and refill_rate must be positive")
+	}
+	for _, route := range rateLimit.Routes {
+		if route.Path == "" {
+			return fmt.Errorf("rate_limit route path must not be empty")
+		}
+		if route.Capacity <= 0 || route.RefillRate <= 0 {
+			return fmt.Errorf("rate_limit route %s %s capacity and refill_rate must be positive", route.Method, route.Path)
+		}
+	}
+
+	switch rateLimit.Headers {
+	case "ietf", "legacy", "off":
+	default:
+		return fmt.Errorf("invalid rate_limit headers: %s", rateLimit.Headers)
+	}
+	for _, id := range rateLimit.Identity {
+		switch id.Type {
+		case "header", "query":
+			if id.Name == "" {
+				return fmt.Errorf("rate_limit identity %s requires name", id.Type)
+			}
+		case "jwt":
+			if id.JWKSFile == "" {
+				return fmt.Errorf("rate_limit identity jwt requires jwks_file")
+			}
+		case "mtls", "ip":
+		default:
+			return fmt.Errorf("invalid rate_limit identity type: %s", id.Type)
+		}
+	}
+	for name, group := range rateLimit.Groups {
+		if group.Capacity <= 0 || group.RefillRate <= 0 {
+			return fmt.Errorf("rate_limit group %s capacity and refill_rate must be positive", name)
+		}
+	}
+	for _, cidr := range rateLimit.CIDRs {
+		if _, err := netip.ParsePrefix(cidr.CIDR); err != nil {
+			return fmt.Errorf("invalid rate_limit cidr %q: %w", cidr.CIDR, err)
+		}
+		if cidr.Capacity <= 0 || cidr.RefillRate <= 0 {
+			return fmt.Errorf("rate_limit cidr %s capacity and refill_rate must be positive", cidr.CIDR)
+		}
+	}
+	for _, proxy := range rateLimit.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			return fmt.Errorf("invalid rate_limit trusted proxy %q: %w", proxy, err)
+		}
+	}
+	if rateLimit.Cost.Default <= 0 {
+		return fmt.Errorf("rate_limit cost default must be positive")
+	}
+	for _, rule := range rateLimit.Cost.Rules {
+		if rule.Cost <= 0 {
+			return fmt.Errorf("rate_limit cost rule %s %s cost must be positive", rule.Method, rule.Path)
+		}
+		if rule.MinContentLength < 0 {
+			return fmt.Errorf("rate_limit cost rule %s %s min_content_length must not be negative", rule.Method, rule.Path)
+		}
+		if rule.HeaderValue != "" && rule.Header == "" {
+			return fmt.Errorf("rate_limit cost rule %s %s header_value requires header", rule.Method, rule.Path)
+		}
+	}
+
+	if rateLimit.Concurrency.MaxInFlight < 0 {
+		return fmt.Errorf("rate_limit concurrency max_in_flight must not be negative")
+	}
+	if rateLimit.Concurrency.LeaseTTL <= 0 {
+		return fmt.Errorf("rate_limit concurrency lease_ttl must be positive")
+	}
+
+	return nil
+}
+
+func validateAccessLog(accessLog *AccessLogConfig) error {
+	if !accessLog.Enabled {
+		return nil
+	}
+
+	validFormats := map[string]bool{
+		"json":     true,
+		"combined": true,
+		"template": true,
+	}
+	if !validFormats[accessLog.Format] {
+		return fmt.Errorf("invalid access_log format: %s", accessLog.Format)
+	}
+	if accessLog.Format == "template" && accessLog.Template == "" {
+		return fmt.Errorf("access_log template must be specified when format is set to template")
+	}
+
+	if accessLog.Output != "stdout" && accessLog.Output != "file" {
+		return fmt.Errorf("invalid access_log output: %s", accessLog.Output)
+	}
+	if accessLog.Output == "file" && accessLog.FilePath == "" {
+		return fmt.Errorf("access_log file_path must be specified when output is set to file")
+	}
+	if err := validateRotation("access_log", accessLog.Rotation); err != nil {
+		return err
+	}
+
+	validClasses := map[string]bool{"1xx": true, "2xx": true, "3xx": true, "4xx": true, "5xx": true}
+	for class, rate := range accessLog.Sampling {
+		if !validClasses[class] {
+			return fmt.Errorf("invalid access_log sampling status class: %s", class)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("access_log sampling rate for %s must be in range [0, 1]", class)
+		}
+	}
+
+	return nil
+}
+
+func validateRotation(section string, rotation RotationConfig) error {
+	if rotation.MaxSizeMB < 0 {
+		return fmt.Errorf("%s rotation max_size_mb must not be negative", section)
+	}
+	if rotation.Interval < 0 {
+		return fmt.Errorf("%s rotation interval must not be negative", section)
+	}
+	if rotation.MaxBackups < 0 {
+		return fmt.Errorf("%s rotation max_backups must not be negative", section)
+	}
+	return nil
+}
+
+// validateBackendURL проверяет, что на адрес бэкенда можно проксировать:
+// схема http или https и непустой хост.
+func validateBackendURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("backend %s: invalid url: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("backend %s: url scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("backend %s: url must have a host", raw)
+	}
+	return nil
+}
+
+func validateDiscovery(discovery *DiscoveryConfig) error {
+	validDNSTypes := map[string]bool{
+		"a":   true,
+		"srv": true,
+	}
+
+	for i := range discovery.DNS {
+		source := &discovery.DNS[i]
+
+		if source.Name == "" {
+			return fmt.Errorf("dns discovery: name must be specified")
+		}
+
+		source.Type = strings.ToLower(source.Type)
+		if source.Type == "" {
+			source.Type = "a"
+		}
+		if !validDNSTypes[source.Type] {
+			return fmt.Errorf("dns discovery %s: invalid record type: %s", source.Name, source.Type)
+		}
+
+		if source.Type == "a" && (source.Port <= 0 || source.Port > 65535) {
+			return fmt.Errorf("dns discovery %s: port must be between 1 and 65535", source.Name)
+		}
+
+		if source.Scheme == "" {
+			source.Scheme = "http"
+		}
+		if source.Scheme != "http" && source.Scheme != "https" {
+			return fmt.Errorf("dns discovery %s: invalid scheme: %s", source.Name, source.Scheme)
+		}
+
+		if source.Interval <= 0 {
+			source.Interval = 30 * time.Second
+		}
+		if source.MinInterval <= 0 {
+			source.MinInterval = time.Second
+		}
+	}
+
+	for i := range discovery.File {
+		source := &discovery.File[i]
+
+		if source.Path == "" {
+			return fmt.Errorf("file discovery: path must be specified")
+		}
+
+		if source.Interval <= 0 {
+			source.Interval = 30 * time.Second
+		}
+	}
+
+	for i := range discovery.HTTP {
+		source := &discovery.HTTP[i]
+
+		if !strings.HasPrefix(source.URL, "http://") && !strings.HasPrefix(source.URL, "https://") {
+			return fmt.Errorf("http discovery: url must be an http or https URL")
+		}
+
+		if source.Interval <= 0 {
+			source.Interval = 30 * time.Second
+		}
+		if source.Timeout <= 0 {
+			source.Timeout = 5 * time.Second
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,246 @@
+package accesslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatCombined = "combined"
+	FormatTemplate = "template"
+)
+
+// Entry - одна запись журнала доступа.
+type Entry struct {
+	Time            time.Time     `json:"time"`
+	RequestID       string        `json:"request_id,omitempty"`
+	RemoteAddr      string        `json:"remote_addr"`
+	ClientID        string        `json:"client_id,omitempty"`
+	Method          string        `json:"method"`
+	URI             string        `json:"uri"`
+	Proto           string        `json:"proto"`
+	Host            string        `json:"host"`
+	Status          int           `json:"status"`
+	BytesIn         int64         `json:"bytes_in"`
+	BytesOut        int64         `json:"bytes_out"`
+	Duration        time.Duration `json:"-"`
+	Upstream        string        `json:"upstream,omitempty"`
+	UpstreamLatency time.Duration `json:"-"`
+	RateLimit       string        `json:"ratelimit,omitempty"`
+	RateLimitCost   int           `json:"ratelimit_cost,omitempty"`
+	Referer         string        `json:"referer,omitempty"`
+	UserAgent       string        `json:"user_agent,omitempty"`
+	TLS             *TLSInfo      `json:"tls,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+type TLSInfo struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name,omitempty"`
+}
+
+func NewTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+
+	return &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+}
+
+// DurationMs и UpstreamLatencyMs удобны для пользовательских шаблонов.
+func (e *Entry) DurationMs() float64 {
+	return float64(e.Duration.Microseconds()) / 1000
+}
+
+func (e *Entry) UpstreamLatencyMs() float64 {
+	return float64(e.UpstreamLatency.Microseconds()) / 1000
+}
+
+type formatter func(buf *bytes.Buffer, e *Entry) error
+
+// Logger пишет журнал доступа отдельно от журнала приложения.
+type Logger struct {
+	mu       sync.Mutex
+	out      io.Writer
+	file     *logger.RotatingFile
+	format   formatter
+	sampling map[string]float64
+}
+
+func New(cfg config.AccessLogConfig) (*Logger, error) {
+	format, err := newFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Logger{
+		out:      os.Stdout,
+		format:   format,
+		sampling: cfg.Sampling,
+	}
+
+	if cfg.Output == "file" {
+		file, err := logger.NewRotatingFile(cfg.FilePath, cfg.Rotation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open access log file: %w", err)
+		}
+		l.out = file
+		l.file = file
+	}
+
+	return l, nil
+}
+
+func newFormatter(cfg config.AccessLogConfig) (formatter, error) {
+	switch cfg.Format {
+	case FormatJSON:
+		return formatJSON, nil
+	case FormatCombined:
+		return formatCombined, nil
+	case FormatTemplate:
+		tmpl, err := template.New("access_log").Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid access log template: %w", err)
+		}
+		return func(buf *bytes.Buffer, e *Entry) error {
+			return tmpl.Execute(buf, e)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown access log format: %s", cfg.Format)
+	}
+}
+
+// Sampled решает, попадёт ли запись в журнал. Доля задаётся для класса кода
+// ответа ("2xx", "5xx", ...); классы без настройки пишутся полностью. Запросы,
+// завершившиеся ошибкой проксирования, пишутся всегда.
+func (l *Logger) Sampled(e *Entry) bool {
+	if e.Error != "" {
+		return true
+	}
+
+	rate, ok := l.sampling[metrics.CodeClass(e.Status)]
+	if !ok || rate >= 1 {
+		return true
+	}
+
+	return rand.Float64() < rate
+}
+
+func (l *Logger) Log(e *Entry) error {
+	if !l.Sampled(e) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := l.format(&buf, e); err != nil {
+		return fmt.Errorf("failed to format access log entry: %w", err)
+	}
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := l.out.Write(buf.Bytes())
+	return err
+}
+
+// Reopen заново открывает файл журнала после внешней ротации.
+func (l *Logger) Reopen() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Reopen()
+}
+
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+type jsonEntry struct {
+	*Entry
+	DurationMs        float64 `json:"duration_ms"`
+	UpstreamLatencyMs float64 `json:"upstream_latency_ms,omitempty"`
+}
+
+func formatJSON(buf *bytes.Buffer, e *Entry) error {
+	return json.NewEncoder(buf).Encode(jsonEntry{
+		Entry:             e,
+		DurationMs:        e.DurationMs(),
+		UpstreamLatencyMs: e.UpstreamLatencyMs(),
+	})
+}
+
+// formatCombined пишет запись в формате Apache combined:
+// %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i".
+func formatCombined(buf *bytes.Buffer, e *Entry) error {
+	bytesOut := "-"
+	if e.BytesOut > 0 {
+		bytesOut = strconv.FormatInt(e.BytesOut, 10)
+	}
+
+	_, err := fmt.Fprintf(buf, "%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+		e.RemoteAddr,
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, escapeQuoted(e.URI), e.Proto,
+		e.Status,
+		bytesOut,
+		escapeQuoted(orDash(e.Referer)),
+		escapeQuoted(orDash(e.UserAgent)),
+	)
+	return err
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+var quotedReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+func escapeQuoted(s string) string {
+	return quotedReplacer.Replace(s)
+}
+
+// NewEntry заполняет поля записи, известные до обработки запроса.
+func NewEntry(r *http.Request, start time.Time, remoteAddr, requestID string) *Entry {
+	return &Entry{
+		Time:       start,
+		RequestID:  requestID,
+		RemoteAddr: remoteAddr,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Proto:      r.Proto,
+		Host:       r.Host,
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+		TLS:        NewTLSInfo(r.TLS),
+	}
+}
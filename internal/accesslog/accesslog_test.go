@@ -0,0 +1,153 @@
+package accesslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func testEntry() *Entry {
+	return &Entry{
+		Time:            time.Date(2025, 3, 1, 12, 30, 45, 0, time.UTC),
+		RequestID:       "req-1",
+		RemoteAddr:      "10.0.0.1",
+		Method:          "POST",
+		URI:             "/api/items?id=1",
+		Proto:           "HTTP/1.1",
+		Host:            "lb.local",
+		Status:          201,
+		BytesIn:         12,
+		BytesOut:        345,
+		Duration:        15 * time.Millisecond,
+		Upstream:        "backend1:8080",
+		UpstreamLatency: 12500 * time.Microsecond,
+		RateLimit:       "allowed",
+		UserAgent:       `curl/8.0 "test"`,
+		TLS:             NewTLSInfo(&tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256, ServerName: "lb.local"}),
+	}
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AccessLogConfig
+		want string
+	}{
+		{
+			name: "Combined",
+			cfg:  config.AccessLogConfig{Format: FormatCombined},
+			want: `10.0.0.1 - - [01/Mar/2025:12:30:45 +0000] "POST /api/items?id=1 HTTP/1.1" 201 345 "-" "curl/8.0 \"test\""` + "\n",
+		},
+		{
+			name: "Template",
+			cfg:  config.AccessLogConfig{Format: FormatTemplate, Template: `{{.RequestID}} {{.Status}} {{.Upstream}} {{.UpstreamLatencyMs}}ms {{.TLS.Version}}`},
+			want: "req-1 201 backend1:8080 12.5ms TLS 1.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := newFormatter(tt.cfg)
+			if err != nil {
+				t.Fatalf("newFormatter() error = %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := format(&buf, testEntry()); err != nil {
+				t.Fatalf("format() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := formatJSON(&buf, testEntry()); err != nil {
+		t.Fatalf("formatJSON() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("formatJSON() produced invalid JSON: %v", err)
+	}
+
+	checks := map[string]any{
+		"status":              float64(201),
+		"bytes_in":            float64(12),
+		"bytes_out":           float64(345),
+		"upstream":            "backend1:8080",
+		"upstream_latency_ms": 12.5,
+		"duration_ms":         float64(15),
+		"ratelimit":           "allowed",
+		"request_id":          "req-1",
+	}
+	for key, want := range checks {
+		if got[key] != want {
+			t.Errorf("JSON field %s = %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got["retries"]; ok {
+		t.Errorf("JSON should not contain retries, proxy does not retry requests")
+	}
+	if tlsInfo, _ := got["tls"].(map[string]any); tlsInfo["cipher_suite"] != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("JSON tls = %v", got["tls"])
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	if _, err := New(config.AccessLogConfig{Format: FormatTemplate, Template: "{{.Status"}); err == nil {
+		t.Errorf("New() should fail on invalid template")
+	}
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := New(config.AccessLogConfig{
+		Format:   FormatCombined,
+		Output:   "file",
+		FilePath: path,
+		Sampling: map[string]float64{"2xx": 0, "5xx": 1},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, status := range []int{200, 204, 404, 502} {
+		entry := testEntry()
+		entry.Status = status
+		if err := logger.Log(entry); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+
+	failed := testEntry()
+	failed.Status = 200
+	failed.Error = "connection reset"
+	logger.Log(failed)
+	logger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read access log: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("access log has %d lines, want 404, 502 and the failed request:\n%s", len(lines), data)
+	}
+	for i, status := range []string{" 404 ", " 502 ", " 200 "} {
+		if !strings.Contains(lines[i], status) {
+			t.Errorf("line %d = %s, want status%s", i, lines[i], status)
+		}
+	}
+}
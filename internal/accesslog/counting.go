@@ -0,0 +1,53 @@
+package accesslog
+
+import (
+	"io"
+	"net/http"
+)
+
+// ResponseWriter считает байты тела ответа.
+type ResponseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.written
+}
+
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Body считает прочитанные байты тела запроса.
+type Body struct {
+	io.ReadCloser
+	read int64
+}
+
+func NewBody(body io.ReadCloser) *Body {
+	return &Body{ReadCloser: body}
+}
+
+func (b *Body) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *Body) BytesRead() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.read
+}
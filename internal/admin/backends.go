@@ -0,0 +1,377 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
+
+	"github.com/rs/zerolog/log"
+)
+
+const drainPollInterval = 100 * time.Millisecond
+
+var (
+	ErrBackendExists   = errors.New("backend already registered")
+	ErrBackendNotFound = errors.New("backend not found")
+)
+
+// BackendPool изменяет набор бэкендов. Реализация сохраняет изменение в
+// StateStore и применяет его к балансировщику атомарно относительно
+// обновлений из конфигурации и источников обнаружения.
+type BackendPool interface {
+	AddBackend(spec BackendSpec) (*balancer.Backend, error)
+	RemoveBackend(backendURL string) error
+	SetBackendEnabled(backendURL string, enabled bool) (*balancer.Backend, error)
+}
+
+type BackendManager struct {
+	balancer balancer.Balancer
+	backends BackendPool
+	maxWait  time.Duration
+}
+
+type BackendRequest struct {
+	URL      string            `json:"url"`
+	Weight   int               `json:"weight"`
+	Priority int               `json:"priority"`
+	Zone     string            `json:"zone"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type BackendInfo struct {
+	URL           string            `json:"url"`
+	Weight        int               `json:"weight"`
+	Priority      int               `json:"priority"`
+	Zone          string            `json:"zone,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	IsAlive       bool              `json:"is_alive"`
+	IsDraining    bool              `json:"is_draining"`
+	IsEnabled     bool              `json:"is_enabled"`
+	ActiveConns   int32             `json:"active_connections"`
+	TotalRequests int64             `json:"total_requests"`
+	FailedReqs    int64             `json:"failed_requests"`
+}
+
+type DrainRequest struct {
+	URL     string `json:"url"`
+	Wait    bool   `json:"wait"`
+	Timeout string `json:"timeout"`
+}
+
+type DrainResponse struct {
+	URL         string `json:"url"`
+	Draining    bool   `json:"draining"`
+	ActiveConns int32  `json:"active_connections"`
+	Drained     bool   `json:"drained"`
+	TimedOut    bool   `json:"timed_out"`
+	WaitedMs    int64  `json:"waited_ms"`
+}
+
+func NewBackendManager(lb balancer.Balancer, backends BackendPool, cfg *config.Config) *BackendManager {
+	return &BackendManager{
+		balancer: lb,
+		backends: backends,
+		// Ожидание не должно упираться в WriteTimeout HTTP-сервера.
+		maxWait: cfg.Server.Timeout * 9 / 10,
+	}
+}
+
+func (bm *BackendManager) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/backends", bm.HandleCRUD)
+	mux.HandleFunc("/admin/backends/enable", bm.HandleEnable)
+	mux.HandleFunc("/admin/backends/disable", bm.HandleDisable)
+	mux.HandleFunc("/admin/backends/drain", bm.HandleDrain)
+}
+
+func (bm *BackendManager) HandleCRUD(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		bm.HandleListBackends(w, r)
+	case http.MethodPost:
+		bm.HandleAddBackend(w, r)
+	case http.MethodDelete:
+		bm.HandleRemoveBackend(w, r)
+	default:
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (bm *BackendManager) HandleListBackends(w http.ResponseWriter, r *http.Request) {
+	backends := bm.balancer.GetAllBackends()
+
+	resp := make([]BackendInfo, 0, len(backends))
+	for _, backend := range backends {
+		resp = append(resp, newBackendInfo(backend))
+	}
+
+	sendJSONResponse(w, http.StatusOK, resp)
+}
+
+func (bm *BackendManager) HandleAddBackend(w http.ResponseWriter, r *http.Request) {
+	var req BackendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to decode backend request")
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+
+	if msg := validateBackendRequest(&req); msg != "" {
+		sendErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	backend, err := bm.backends.AddBackend(BackendSpec{
+		URL:      req.URL,
+		Weight:   req.Weight,
+		Priority: req.Priority,
+		Zone:     req.Zone,
+		Metadata: req.Metadata,
+	})
+	if errors.Is(err, ErrBackendExists) {
+		sendErrorResponse(w, http.StatusConflict, "Backend already registered")
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("url", req.URL).Msg("Failed to add backend")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to add backend")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusCreated, newBackendInfo(backend))
+}
+
+func (bm *BackendManager) HandleRemoveBackend(w http.ResponseWriter, r *http.Request) {
+	backendURL := r.URL.Query().Get("url")
+	if backendURL == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Backend URL is required")
+		return
+	}
+
+	err := bm.backends.RemoveBackend(backendURL)
+	if errors.Is(err, ErrBackendNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Backend not found")
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("url", backendURL).Msg("Failed to remove backend")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to remove backend")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (bm *BackendManager) HandleEnable(w http.ResponseWriter, r *http.Request) {
+	bm.handleSetEnabled(w, r, true)
+}
+
+func (bm *BackendManager) HandleDisable(w http.ResponseWriter, r *http.Request) {
+	bm.handleSetEnabled(w, r, false)
+}
+
+func (bm *BackendManager) handleSetEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req BackendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to decode backend request")
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+
+	if req.URL == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Backend URL is required")
+		return
+	}
+
+	backend, err := bm.backends.SetBackendEnabled(req.URL, enabled)
+	if errors.Is(err, ErrBackendNotFound) {
+		sendErrorResponse(w, http.StatusNotFound, "Backend not found")
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("url", req.URL).Msg("Failed to update backend")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to update backend")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, newBackendInfo(backend))
+}
+
+func (bm *BackendManager) HandleDrain(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		bm.HandleStartDrain(w, r)
+	case http.MethodDelete:
+		bm.HandleStopDrain(w, r)
+	default:
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (bm *BackendManager) HandleStartDrain(w http.ResponseWriter, r *http.Request) {
+	var req DrainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to decode drain request")
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+
+	if req.URL == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Backend URL is required")
+		return
+	}
+
+	timeout := bm.maxWait
+	if req.Timeout != "" {
+		parsed, err := time.ParseDuration(req.Timeout)
+		if err != nil || parsed <= 0 {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid timeout")
+			return
+		}
+		timeout = min(parsed, bm.maxWait)
+	}
+
+	backend := balancer.FindBackend(bm.balancer, req.URL)
+	if backend == nil {
+		sendErrorResponse(w, http.StatusNotFound, "Backend not found")
+		return
+	}
+
+	backend.StartDraining()
+
+	resp := DrainResponse{
+		URL:      backend.URL.String(),
+		Draining: true,
+	}
+
+	if req.Wait {
+		start := time.Now()
+		resp.Drained = waitForDrain(r.Context(), backend, timeout)
+		resp.TimedOut = !resp.Drained
+		resp.WaitedMs = time.Since(start).Milliseconds()
+	} else {
+		resp.Drained = backend.GetActiveConns() == 0
+	}
+	resp.ActiveConns = backend.GetActiveConns()
+
+	log.Ctx(r.Context()).Info().
+		Str("backend", resp.URL).
+		Bool("wait", req.Wait).
+		Bool("drained", resp.Drained).
+		Int32("active_connections", resp.ActiveConns).
+		Msg("Backend drain requested")
+
+	sendJSONResponse(w, http.StatusOK, resp)
+}
+
+func (bm *BackendManager) HandleStopDrain(w http.ResponseWriter, r *http.Request) {
+	backendURL := r.URL.Query().Get("url")
+	if backendURL == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Backend URL is required")
+		return
+	}
+
+	backend := balancer.FindBackend(bm.balancer, backendURL)
+	if backend == nil {
+		sendErrorResponse(w, http.StatusNotFound, "Backend not found")
+		return
+	}
+
+	backend.StopDraining()
+
+	sendJSONResponse(w, http.StatusOK, DrainResponse{
+		URL:         backend.URL.String(),
+		Draining:    false,
+		ActiveConns: backend.GetActiveConns(),
+	})
+}
+
+func validateBackendRequest(req *BackendRequest) string {
+	if req.URL == "" {
+		return "Backend URL is required"
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Backend URL must be an absolute http or https URL"
+	}
+
+	if req.Weight < 0 {
+		return "Weight must not be negative"
+	}
+
+	if req.Priority < 0 {
+		return "Priority must not be negative"
+	}
+
+	return ""
+}
+
+func newBackendInfo(backend *balancer.Backend) BackendInfo {
+	status := backend.GetStatus()
+
+	return BackendInfo{
+		URL:           backend.URL.String(),
+		Weight:        backend.GetWeight(),
+		Priority:      backend.GetPriority(),
+		Zone:          backend.GetZone(),
+		Metadata:      backend.GetMetadata(),
+		IsAlive:       status.IsAlive,
+		IsDraining:    status.IsDraining,
+		IsEnabled:     !status.IsDisabled,
+		ActiveConns:   status.ActiveConns,
+		TotalRequests: status.TotalRequests,
+		FailedReqs:    status.FailedReqs,
+	}
+}
+
+func waitForDrain(ctx context.Context, backend *balancer.Backend, timeout time.Duration) bool {
+	if backend.GetActiveConns() == 0 {
+		return true
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			return backend.GetActiveConns() == 0
+		case <-ticker.C:
+			if backend.GetActiveConns() == 0 {
+				return true
+			}
+		}
+	}
+}
+
+func sendJSONResponse(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error().Err(err).Msg("Failed to encode response")
+	}
+}
+
+func sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	sendJSONResponse(w, statusCode, ratelimit.ErrorResponse{
+		Code:    statusCode,
+		Message: message,
+	})
+}
@@ -0,0 +1,462 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
+)
+
+// testPool повторяет поведение discovery.Manager поверх одного балансировщика.
+type testPool struct {
+	lb    balancer.Balancer
+	state *StateStore
+	err   error
+}
+
+func (p *testPool) AddBackend(spec BackendSpec) (*balancer.Backend, error) {
+	if balancer.FindBackend(p.lb, spec.URL) != nil {
+		return nil, ErrBackendExists
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := p.state.RecordAdd(spec); err != nil {
+		return nil, err
+	}
+
+	backend, err := balancer.NewBackendFromConfig(config.BackendConfig{
+		URL:      spec.URL,
+		Weight:   spec.Weight,
+		Priority: spec.Priority,
+		Zone:     spec.Zone,
+		Metadata: spec.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.lb.RegisterBackend(backend)
+
+	return backend, nil
+}
+
+func (p *testPool) RemoveBackend(backendURL string) error {
+	backend := balancer.FindBackend(p.lb, backendURL)
+	if backend == nil {
+		return ErrBackendNotFound
+	}
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.state.RecordRemove(backend.URL.String()); err != nil {
+		return err
+	}
+	p.lb.RemoveBackend(backend)
+
+	return nil
+}
+
+func (p *testPool) SetBackendEnabled(backendURL string, enabled bool) (*balancer.Backend, error) {
+	backend := balancer.FindBackend(p.lb, backendURL)
+	if backend == nil {
+		return nil, ErrBackendNotFound
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := p.state.RecordEnabled(backend.URL.String(), enabled); err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		backend.Enable()
+	} else {
+		backend.Disable()
+	}
+
+	return backend, nil
+}
+
+func newTestBackendManager(t *testing.T, urls ...string) (*BackendManager, *testPool) {
+	t.Helper()
+
+	state, err := NewStateStore("")
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+
+	var backends []*balancer.Backend
+	for _, u := range urls {
+		backend, err := balancer.NewBackend(u)
+		if err != nil {
+			t.Fatalf("Failed to create backend: %v", err)
+		}
+		backends = append(backends, backend)
+	}
+
+	pool := &testPool{
+		lb:    balancer.NewRoundRobinBalancer(backends),
+		state: state,
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+
+	return NewBackendManager(pool.lb, pool, cfg), pool
+}
+
+func serve(bm *BackendManager, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	bm.RegisterHandlers(mux)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	return w
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) ratelimit.ErrorResponse {
+	t.Helper()
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON error, got Content-Type %q", ct)
+	}
+
+	var resp ratelimit.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if resp.Code != w.Code {
+		t.Errorf("Expected error code %d in body, got %d", w.Code, resp.Code)
+	}
+
+	return resp
+}
+
+func TestBackendManager_ListBackends(t *testing.T) {
+	bm, _ := newTestBackendManager(t, "http://backend1", "http://backend2")
+
+	w := serve(bm, http.MethodGet, "/admin/backends", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var backends []BackendInfo
+	if err := json.NewDecoder(w.Body).Decode(&backends); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(backends) != 2 {
+		t.Fatalf("Expected 2 backends, got %d", len(backends))
+	}
+	if backends[0].URL != "http://backend1" || !backends[0].IsEnabled || backends[0].Weight != 1 {
+		t.Errorf("Unexpected backend info: %+v", backends[0])
+	}
+}
+
+func TestBackendManager_AddBackend(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "Valid backend",
+			body:       `{"url": "http://backend2", "weight": 3, "zone": "a", "metadata": {"version": "1.2.0"}}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Invalid JSON",
+			body:       `{"url": `,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid request format",
+		},
+		{
+			name:       "Missing URL",
+			body:       `{"weight": 1}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Backend URL is required",
+		},
+		{
+			name:       "Relative URL",
+			body:       `{"url": "backend2"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Backend URL must be an absolute http or https URL",
+		},
+		{
+			name:       "Negative weight",
+			body:       `{"url": "http://backend2", "weight": -1}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Weight must not be negative",
+		},
+		{
+			name:       "Negative priority",
+			body:       `{"url": "http://backend2", "priority": -1}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Priority must not be negative",
+		},
+		{
+			name:       "Already registered",
+			body:       `{"url": "http://backend1"}`,
+			wantStatus: http.StatusConflict,
+			wantError:  "Backend already registered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bm, pool := newTestBackendManager(t, "http://backend1")
+
+			w := serve(bm, http.MethodPost, "/admin/backends", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			if tt.wantError != "" {
+				if resp := decodeError(t, w); resp.Message != tt.wantError {
+					t.Errorf("Expected error %q, got %q", tt.wantError, resp.Message)
+				}
+				return
+			}
+
+			var info BackendInfo
+			if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if info.URL != "http://backend2" || info.Weight != 3 || info.Zone != "a" || info.Metadata["version"] != "1.2.0" {
+				t.Errorf("Unexpected backend info: %+v", info)
+			}
+			if balancer.FindBackend(pool.lb, "http://backend2") == nil {
+				t.Error("Expected backend to be registered")
+			}
+		})
+	}
+}
+
+func TestBackendManager_RemoveBackend(t *testing.T) {
+	bm, pool := newTestBackendManager(t, "http://backend1")
+
+	w := serve(bm, http.MethodDelete, "/admin/backends", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without url, got %d", w.Code)
+	}
+
+	w = serve(bm, http.MethodDelete, "/admin/backends?url=http://unknown", "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", w.Code)
+	}
+	if resp := decodeError(t, w); resp.Message != "Backend not found" {
+		t.Errorf("Unexpected error message %q", resp.Message)
+	}
+
+	w = serve(bm, http.MethodDelete, "/admin/backends?url=http://backend1", "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if balancer.FindBackend(pool.lb, "http://backend1") != nil {
+		t.Error("Expected backend to be removed")
+	}
+}
+
+func TestBackendManager_SetEnabled(t *testing.T) {
+	bm, pool := newTestBackendManager(t, "http://backend1")
+
+	w := serve(bm, http.MethodGet, "/admin/backends/disable", "")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+
+	w = serve(bm, http.MethodPost, "/admin/backends/disable", `{}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without url, got %d", w.Code)
+	}
+
+	w = serve(bm, http.MethodPost, "/admin/backends/disable", `{"url": "http://unknown"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w = serve(bm, http.MethodPost, "/admin/backends/disable", `{"url": "http://backend1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var info BackendInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.IsEnabled {
+		t.Error("Expected backend to be disabled")
+	}
+	if !pool.state.IsDisabled("http://backend1") {
+		t.Error("Expected disabled backend to be recorded in state")
+	}
+
+	w = serve(bm, http.MethodPost, "/admin/backends/enable", `{"url": "http://backend1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !balancer.FindBackend(pool.lb, "http://backend1").Enabled() {
+		t.Error("Expected backend to be enabled")
+	}
+}
+
+func TestBackendManager_PersistError(t *testing.T) {
+	bm, pool := newTestBackendManager(t, "http://backend1")
+	pool.err = errors.New("disk full")
+
+	requests := []struct {
+		method string
+		target string
+		body   string
+	}{
+		{http.MethodPost, "/admin/backends", `{"url": "http://backend2"}`},
+		{http.MethodDelete, "/admin/backends?url=http://backend1", ""},
+		{http.MethodPost, "/admin/backends/disable", `{"url": "http://backend1"}`},
+	}
+
+	for _, req := range requests {
+		w := serve(bm, req.method, req.target, req.body)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s %s: expected status 500, got %d", req.method, req.target, w.Code)
+			continue
+		}
+		decodeError(t, w)
+	}
+}
+
+func decodeDrain(t *testing.T, w *httptest.ResponseRecorder) DrainResponse {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp DrainResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode drain response: %v", err)
+	}
+	return resp
+}
+
+func TestBackendManager_DrainWithoutWait(t *testing.T) {
+	bm, pool := newTestBackendManager(t, "http://backend1")
+	backend := balancer.FindBackend(pool.lb, "http://backend1")
+	backend.IncrementActiveConns()
+	backend.IncrementActiveConns()
+
+	resp := decodeDrain(t, serve(bm, http.MethodPost, "/admin/backends/drain", `{"url": "http://backend1"}`))
+
+	if !resp.Draining || resp.Drained || resp.TimedOut {
+		t.Errorf("Unexpected drain response: %+v", resp)
+	}
+	if resp.ActiveConns != 2 || resp.WaitedMs != 0 {
+		t.Errorf("Expected 2 active connections without waiting, got %+v", resp)
+	}
+	if !backend.Draining() {
+		t.Error("Expected backend to be draining")
+	}
+
+	resp = decodeDrain(t, serve(bm, http.MethodDelete, "/admin/backends/drain?url=http://backend1", ""))
+	if resp.Draining || backend.Draining() {
+		t.Error("Expected backend to return to rotation")
+	}
+}
+
+func TestBackendManager_DrainWait(t *testing.T) {
+	bm, pool := newTestBackendManager(t, "http://backend1")
+	backend := balancer.FindBackend(pool.lb, "http://backend1")
+	backend.IncrementActiveConns()
+
+	go func() {
+		time.Sleep(3 * drainPollInterval / 2)
+		backend.DecrementActiveConns()
+	}()
+
+	resp := decodeDrain(t, serve(bm, http.MethodPost, "/admin/backends/drain", `{"url": "http://backend1", "wait": true, "timeout": "5s"}`))
+
+	if !resp.Drained || resp.TimedOut || resp.ActiveConns != 0 {
+		t.Errorf("Expected backend to be drained, got %+v", resp)
+	}
+	if resp.WaitedMs < drainPollInterval.Milliseconds() {
+		t.Errorf("Expected to wait for active connections, waited %dms", resp.WaitedMs)
+	}
+}
+
+func TestBackendManager_DrainTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxWait time.Duration
+		timeout string
+	}{
+		{
+			name:    "Requested timeout",
+			maxWait: time.Minute,
+			timeout: "50ms",
+		},
+		{
+			name:    "Clamped to max wait",
+			maxWait: 50 * time.Millisecond,
+			timeout: "1h",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bm, pool := newTestBackendManager(t, "http://backend1")
+			bm.maxWait = tt.maxWait
+			backend := balancer.FindBackend(pool.lb, "http://backend1")
+			backend.IncrementActiveConns()
+
+			start := time.Now()
+			resp := decodeDrain(t, serve(bm, http.MethodPost, "/admin/backends/drain",
+				`{"url": "http://backend1", "wait": true, "timeout": "`+tt.timeout+`"}`))
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Expected drain to give up after 50ms, took %v", elapsed)
+			}
+			if resp.Drained || !resp.TimedOut || resp.ActiveConns != 1 {
+				t.Errorf("Expected drain to time out, got %+v", resp)
+			}
+			if resp.WaitedMs < 50 {
+				t.Errorf("Expected to wait at least 50ms, waited %dms", resp.WaitedMs)
+			}
+		})
+	}
+}
+
+func TestBackendManager_DrainErrors(t *testing.T) {
+	bm, _ := newTestBackendManager(t, "http://backend1")
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"Unknown backend", http.MethodPost, "/admin/backends/drain", `{"url": "http://unknown"}`, http.StatusNotFound},
+		{"Missing URL", http.MethodPost, "/admin/backends/drain", `{}`, http.StatusBadRequest},
+		{"Invalid timeout", http.MethodPost, "/admin/backends/drain", `{"url": "http://backend1", "timeout": "soon"}`, http.StatusBadRequest},
+		{"Negative timeout", http.MethodPost, "/admin/backends/drain", `{"url": "http://backend1", "timeout": "-1s"}`, http.StatusBadRequest},
+		{"Stop unknown backend", http.MethodDelete, "/admin/backends/drain?url=http://unknown", "", http.StatusNotFound},
+		{"Wrong method", http.MethodGet, "/admin/backends/drain", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(bm, tt.method, tt.target, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			decodeError(t, w)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LogLevelRequest меняет общий уровень логирования или, если задан
+// Component, уровень подсистемы. Уровень "inherit" возвращает подсистему к
+// общему уровню. При заданном Duration уровень откатится через это время.
+type LogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+	Duration  string `json:"duration"`
+}
+
+const inheritLevel = "inherit"
+
+func HandleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sendJSONResponse(w, http.StatusOK, logger.Levels())
+	case http.MethodPut, http.MethodPost:
+		handleSetLogLevel(w, r)
+	default:
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to decode log level request")
+		sendErrorResponse(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+
+	if req.Component != "" && !slices.Contains(logger.Components, req.Component) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown component")
+		return
+	}
+
+	var level zerolog.Level
+	if req.Component != "" && strings.EqualFold(req.Level, inheritLevel) {
+		level = zerolog.NoLevel
+	} else {
+		parsed, err := zerolog.ParseLevel(strings.ToLower(req.Level))
+		if err != nil || req.Level == "" || parsed == zerolog.NoLevel {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid log level")
+			return
+		}
+		level = parsed
+	}
+
+	var revertAfter time.Duration
+	if req.Duration != "" {
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil || parsed <= 0 {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid duration")
+			return
+		}
+		revertAfter = parsed
+	}
+
+	if req.Component == "" {
+		logger.SetLevel(level, revertAfter)
+	} else if err := logger.SetComponentLevel(req.Component, level, revertAfter); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	log.Ctx(r.Context()).Info().
+		Str("log_component", req.Component).
+		Str("level", req.Level).
+		Dur("revert_after", revertAfter).
+		Msg("Log level changed")
+
+	sendJSONResponse(w, http.StatusOK, logger.Levels())
+}
@@ -0,0 +1,182 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+// StateStore хранит изменения набора бэкендов, сделанные через API,
+// поверх списка из конфигурации.
+type StateStore struct {
+	path  string
+	mu    sync.Mutex
+	state backendState
+}
+
+type backendState struct {
+	Added    []BackendSpec `json:"added"`
+	Removed  []string      `json:"removed"`
+	Disabled []string      `json:"disabled"`
+}
+
+type BackendSpec struct {
+	URL      string            `json:"url"`
+	Weight   int               `json:"weight,omitempty"`
+	Priority int               `json:"priority,omitempty"`
+	Zone     string            `json:"zone,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func NewStateStore(path string) (*StateStore, error) {
+	s := &StateStore{
+		path: path,
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backend state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to decode backend state file: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *StateStore) Enabled() bool {
+	return s.path != ""
+}
+
+func (s *StateStore) Apply(backends []config.BackendConfig) []config.BackendConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]config.BackendConfig, 0, len(backends)+len(s.state.Added))
+	for _, backend := range backends {
+		if !slices.Contains(s.state.Removed, backend.URL) {
+			result = append(result, backend)
+		}
+	}
+
+	for _, spec := range s.state.Added {
+		result = append(result, config.BackendConfig{
+			URL:      spec.URL,
+			Weight:   spec.Weight,
+			Priority: spec.Priority,
+			Zone:     spec.Zone,
+			Metadata: spec.Metadata,
+		})
+	}
+
+	return result
+}
+
+func (s *StateStore) IsDisabled(backendURL string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Contains(s.state.Disabled, backendURL)
+}
+
+func (s *StateStore) RecordAdd(spec BackendSpec) error {
+	return s.update(func(state *backendState) {
+		if i := slices.Index(state.Removed, spec.URL); i >= 0 {
+			state.Removed = slices.Delete(state.Removed, i, i+1)
+		}
+		state.Added = append(state.Added, spec)
+	})
+}
+
+func (s *StateStore) RecordRemove(backendURL string) error {
+	return s.update(func(state *backendState) {
+		i := slices.IndexFunc(state.Added, func(spec BackendSpec) bool {
+			return spec.URL == backendURL
+		})
+		if i >= 0 {
+			state.Added = slices.Delete(state.Added, i, i+1)
+		} else if !slices.Contains(state.Removed, backendURL) {
+			state.Removed = append(state.Removed, backendURL)
+		}
+
+		if i := slices.Index(state.Disabled, backendURL); i >= 0 {
+			state.Disabled = slices.Delete(state.Disabled, i, i+1)
+		}
+	})
+}
+
+func (s *StateStore) RecordEnabled(backendURL string, enabled bool) error {
+	return s.update(func(state *backendState) {
+		i := slices.Index(state.Disabled, backendURL)
+		if enabled && i >= 0 {
+			state.Disabled = slices.Delete(state.Disabled, i, i+1)
+		}
+		if !enabled && i < 0 {
+			state.Disabled = append(state.Disabled, backendURL)
+		}
+	})
+}
+
+func (s *StateStore) update(fn func(state *backendState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Изменения применяются к копии и принимаются только после записи файла,
+	// чтобы при ошибке состояние в памяти не расходилось с диском.
+	next := s.state.clone()
+	fn(&next)
+
+	if s.path == "" {
+		s.state = next
+		return nil
+	}
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backend state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write backend state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write backend state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write backend state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write backend state: %w", err)
+	}
+
+	s.state = next
+
+	return nil
+}
+
+func (s backendState) clone() backendState {
+	return backendState{
+		Added:    slices.Clone(s.Added),
+		Removed:  slices.Clone(s.Removed),
+		Disabled: slices.Clone(s.Disabled),
+	}
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func configURLs(backends []config.BackendConfig) []string {
+	urls := make([]string, 0, len(backends))
+	for _, backend := range backends {
+		urls = append(urls, backend.URL)
+	}
+	return urls
+}
+
+func TestStateStore_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	store, err := NewStateStore(path)
+	if err != nil {
+		t.Fatalf("NewStateStore() error = %v", err)
+	}
+
+	if err := store.RecordAdd(BackendSpec{URL: "http://backend3", Weight: 2, Zone: "a"}); err != nil {
+		t.Fatalf("RecordAdd() error = %v", err)
+	}
+	if err := store.RecordRemove("http://backend1"); err != nil {
+		t.Fatalf("RecordRemove() error = %v", err)
+	}
+	if err := store.RecordEnabled("http://backend2", false); err != nil {
+		t.Fatalf("RecordEnabled() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read state dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		t.Errorf("Expected only the renamed state file, got %v", entries)
+	}
+
+	static := []config.BackendConfig{{URL: "http://backend1"}, {URL: "http://backend2"}}
+
+	reloaded, err := NewStateStore(path)
+	if err != nil {
+		t.Fatalf("NewStateStore() reload error = %v", err)
+	}
+
+	for name, s := range map[string]*StateStore{"memory": store, "disk": reloaded} {
+		applied := s.Apply(static)
+		if got, want := configURLs(applied), []string{"http://backend2", "http://backend3"}; !slices.Equal(got, want) {
+			t.Errorf("%s: Apply() = %v, want %v", name, got, want)
+		}
+		if applied[1].Weight != 2 || applied[1].Zone != "a" {
+			t.Errorf("%s: expected added backend settings to be kept, got %+v", name, applied[1])
+		}
+		if !s.IsDisabled("http://backend2") {
+			t.Errorf("%s: expected backend2 to be disabled", name)
+		}
+	}
+
+	// Повторное добавление отменяет удаление, удаление добавленного - добавление.
+	if err := reloaded.RecordAdd(BackendSpec{URL: "http://backend1"}); err != nil {
+		t.Fatalf("RecordAdd() error = %v", err)
+	}
+	if err := reloaded.RecordRemove("http://backend3"); err != nil {
+		t.Fatalf("RecordRemove() error = %v", err)
+	}
+
+	if got, want := configURLs(reloaded.Apply(static)), []string{"http://backend1", "http://backend2", "http://backend1"}; !slices.Equal(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestStateStore_FailedWriteKeepsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "state.json")
+
+	store, err := NewStateStore(path)
+	if err != nil {
+		t.Fatalf("NewStateStore() error = %v", err)
+	}
+
+	if err := store.RecordAdd(BackendSpec{URL: "http://backend2"}); err == nil {
+		t.Fatal("Expected write into a missing directory to fail")
+	}
+	if err := store.RecordEnabled("http://backend1", false); err == nil {
+		t.Fatal("Expected write into a missing directory to fail")
+	}
+
+	static := []config.BackendConfig{{URL: "http://backend1"}}
+	if got := configURLs(store.Apply(static)); !slices.Equal(got, []string{"http://backend1"}) {
+		t.Errorf("Expected state to be unchanged after failed write, got %v", got)
+	}
+	if store.IsDisabled("http://backend1") {
+		t.Error("Expected failed disable to be discarded")
+	}
+}
@@ -4,17 +4,22 @@ import (
 	"context"
 	"errors"
 	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/latency"
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
 	"net/url"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/rs/zerolog/log"
 )
 
+var log = logger.For(logger.Balancer)
+
 type BackendStatus struct {
 	URL           *url.URL
 	IsAlive       bool
+	IsDraining    bool
+	IsDisabled    bool
 	ActiveConns   int32
 	LastChecked   time.Time
 	FailureCount  int32
@@ -24,12 +29,20 @@ type BackendStatus struct {
 
 type Backend struct {
 	URL           *url.URL
+	Weight        atomic.Int32
+	Priority      atomic.Int32
+	Zone          atomic.Value
+	Metadata      atomic.Value
 	IsAlive       atomic.Bool
+	IsDraining    atomic.Bool
+	IsDisabled    atomic.Bool
+	AliveSince    atomic.Value
 	ActiveConns   atomic.Int32
 	LastChecked   atomic.Value
 	FailureCount  atomic.Int32
 	TotalRequests atomic.Int64
 	FailedReqs    atomic.Int64
+	Latency       *latency.Tracker
 }
 
 func NewBackend(backendURL string) (*Backend, error) {
@@ -38,15 +51,70 @@ func NewBackend(backendURL string) (*Backend, error) {
 		return nil, err
 	}
 
+	now := time.Now()
+
 	b := &Backend{
-		URL: u,
+		URL:     u,
+		Latency: latency.NewTracker(),
 	}
+	b.Weight.Store(1)
+	b.Zone.Store("")
+	b.Metadata.Store(map[string]string(nil))
 	b.IsAlive.Store(true)
-	b.LastChecked.Store(time.Now())
+	b.AliveSince.Store(now)
+	b.LastChecked.Store(now)
 
 	return b, nil
 }
 
+func NewBackendFromConfig(cfg config.BackendConfig) (*Backend, error) {
+	b, err := NewBackend(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	b.ApplyConfig(cfg)
+
+	return b, nil
+}
+
+func (b *Backend) ApplyConfig(cfg config.BackendConfig) {
+	weight := cfg.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	b.Weight.Store(int32(weight))
+	b.Priority.Store(int32(max(cfg.Priority, 0)))
+	b.Zone.Store(cfg.Zone)
+	b.Metadata.Store(cfg.Metadata)
+}
+
+func (b *Backend) GetWeight() int {
+	if weight := b.Weight.Load(); weight > 0 {
+		return int(weight)
+	}
+	return 1
+}
+
+func (b *Backend) GetPriority() int {
+	return int(b.Priority.Load())
+}
+
+func (b *Backend) GetZone() string {
+	zone, _ := b.Zone.Load().(string)
+	return zone
+}
+
+func (b *Backend) GetMetadata() map[string]string {
+	metadata, _ := b.Metadata.Load().(map[string]string)
+	return metadata
+}
+
+func (b *Backend) GetAliveSince() time.Time {
+	since, _ := b.AliveSince.Load().(time.Time)
+	return since
+}
+
 func (b *Backend) IncrementActiveConns() {
 	b.ActiveConns.Add(1)
 }
@@ -60,9 +128,12 @@ func (b *Backend) GetActiveConns() int32 {
 }
 
 func (b *Backend) MarkUp() {
-	b.IsAlive.Store(true)
+	now := time.Now()
+	if !b.IsAlive.Swap(true) {
+		b.AliveSince.Store(now)
+	}
 	b.FailureCount.Store(0)
-	b.LastChecked.Store(time.Now())
+	b.LastChecked.Store(now)
 	log.Info().Str("backend", b.URL.String()).Msg("Backend marked as UP")
 }
 
@@ -76,6 +147,38 @@ func (b *Backend) IsAvailable() bool {
 	return b.IsAlive.Load()
 }
 
+func (b *Backend) StartDraining() {
+	if !b.IsDraining.Swap(true) {
+		log.Info().Str("backend", b.URL.String()).Msg("Backend draining started")
+	}
+}
+
+func (b *Backend) StopDraining() {
+	if b.IsDraining.Swap(false) {
+		log.Info().Str("backend", b.URL.String()).Msg("Backend draining stopped")
+	}
+}
+
+func (b *Backend) Draining() bool {
+	return b.IsDraining.Load()
+}
+
+func (b *Backend) Disable() {
+	if !b.IsDisabled.Swap(true) {
+		log.Info().Str("backend", b.URL.String()).Msg("Backend disabled")
+	}
+}
+
+func (b *Backend) Enable() {
+	if b.IsDisabled.Swap(false) {
+		log.Info().Str("backend", b.URL.String()).Msg("Backend enabled")
+	}
+}
+
+func (b *Backend) Enabled() bool {
+	return !b.IsDisabled.Load()
+}
+
 func (b *Backend) IncrementFailureCount() {
 	b.FailureCount.Add(1)
 }
@@ -84,6 +187,8 @@ func (b *Backend) GetStatus() BackendStatus {
 	return BackendStatus{
 		URL:           b.URL,
 		IsAlive:       b.IsAlive.Load(),
+		IsDraining:    b.IsDraining.Load(),
+		IsDisabled:    b.IsDisabled.Load(),
 		ActiveConns:   b.ActiveConns.Load(),
 		LastChecked:   b.LastChecked.Load().(time.Time),
 		FailureCount:  b.FailureCount.Load(),
@@ -117,6 +222,8 @@ type Balancer interface {
 	Name() string
 
 	GetStatistics() map[string]BackendStats
+
+	PanicStatus() PanicStatus
 }
 
 var (
@@ -126,23 +233,110 @@ var (
 )
 
 type BaseBalancer struct {
-	backends []*Backend
-	mutex    sync.RWMutex
+	backends               []*Backend
+	mutex                  sync.RWMutex
+	slowStart              slowStart
+	overprovisioningFactor float64
+	zone                   string
+	panicThreshold         float64
+	panicMode              *panicMode
+}
+
+type Option func(*BaseBalancer)
+
+func WithSlowStart(cfg config.SlowStartConfig) Option {
+	return func(b *BaseBalancer) {
+		b.slowStart = newSlowStart(cfg)
+	}
+}
+
+func WithOverprovisioningFactor(factor float64) Option {
+	return func(b *BaseBalancer) {
+		if factor >= 1 {
+			b.overprovisioningFactor = factor
+		}
+	}
+}
+
+func WithZone(zone string) Option {
+	return func(b *BaseBalancer) {
+		b.zone = zone
+	}
+}
+
+// WithPanicThreshold задаёт долю здоровых бэкендов, ниже которой балансировщик
+// перестаёт учитывать результаты проверок здоровья. 0 выключает режим паники.
+func WithPanicThreshold(threshold float64) Option {
+	return func(b *BaseBalancer) {
+		if threshold >= 0 && threshold <= 1 {
+			b.panicThreshold = threshold
+		}
+	}
 }
 
 type BackendStats struct {
-	URL           string  `json:"url"`
-	IsAlive       bool    `json:"is_alive"`
-	ActiveConns   int32   `json:"active_connections"`
-	TotalRequests int64   `json:"total_requests"`
-	FailedReqs    int64   `json:"failed_requests"`
-	FailureRate   float64 `json:"failure_rate,omitempty"`
+	URL             string         `json:"url"`
+	IsAlive         bool           `json:"is_alive"`
+	IsDraining      bool           `json:"is_draining"`
+	IsDisabled      bool           `json:"is_disabled"`
+	Weight          int            `json:"weight"`
+	EffectiveWeight float64        `json:"effective_weight"`
+	Priority        int            `json:"priority"`
+	TierLoad        float64        `json:"tier_load_percent"`
+	Zone            string         `json:"zone,omitempty"`
+	ActiveConns     int32          `json:"active_connections"`
+	TotalRequests   int64          `json:"total_requests"`
+	FailedReqs      int64          `json:"failed_requests"`
+	FailureRate     float64        `json:"failure_rate,omitempty"`
+	Latency         *latency.Stats `json:"latency,omitempty"`
 }
 
-func NewBaseBalancer(backends []*Backend) *BaseBalancer {
-	return &BaseBalancer{
-		backends: backends,
+func NewBaseBalancer(backends []*Backend, opts ...Option) *BaseBalancer {
+	b := &BaseBalancer{
+		backends:               backends,
+		overprovisioningFactor: DefaultOverprovisioningFactor,
+		panicMode:              &panicMode{},
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// candidates возвращает здоровые бэкенды уровня приоритета, выбранного для
+// очередного запроса. Алгоритмы балансировки выбирают бэкенд только среди них.
+func (b *BaseBalancer) candidates() []*Backend {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	tiers := buildTiers(b.backends, b.zone)
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	if b.checkPanic(tiers) {
+		var all []*Backend
+		for _, t := range tiers {
+			all = append(all, t.backends...)
+		}
+		return all
+	}
+
+	selected := &tiers[0]
+	if len(tiers) > 1 {
+		selected = pickTier(tiers, tierLoads(tiers, b.overprovisioningFactor))
 	}
+	if selected == nil {
+		return nil
+	}
+
+	return pickZone(selected, b.overprovisioningFactor)
+}
+
+func (b *BaseBalancer) EffectiveWeight(backend *Backend, now time.Time) float64 {
+	return float64(backend.GetWeight()) * b.slowStart.factor(backend.GetAliveSince(), now)
 }
 
 func (b *BaseBalancer) RegisterBackend(backend *Backend) {
@@ -156,6 +350,7 @@ func (b *BaseBalancer) RegisterBackend(backend *Backend) {
 		}
 	}
 
+	backend.AliveSince.Store(time.Now())
 	b.backends = append(b.backends, backend)
 	log.Info().Str("url", backend.URL.String()).Msg("Backend registered")
 }
@@ -203,7 +398,7 @@ func (b *BaseBalancer) GetHealthyBackends() []*Backend {
 
 	var healthy []*Backend
 	for _, backend := range b.backends {
-		if backend.IsAvailable() {
+		if backend.IsAvailable() && !backend.Draining() && backend.Enabled() {
 			healthy = append(healthy, backend)
 		}
 	}
@@ -221,11 +416,26 @@ func (b *BaseBalancer) GetAllBackends() []*Backend {
 	return result
 }
 
+func FindBackend(balancer Balancer, backendURL string) *Backend {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return nil
+	}
+
+	for _, backend := range balancer.GetAllBackends() {
+		if backend.URL.String() == u.String() {
+			return backend
+		}
+	}
+
+	return nil
+}
+
 func BalancerFactory(cfg *config.Config) (Balancer, error) {
 
 	var backends []*Backend
 	for _, backendCfg := range cfg.Backends {
-		backend, err := NewBackend(backendCfg.URL)
+		backend, err := NewBackendFromConfig(backendCfg)
 		if err != nil {
 			log.Error().Err(err).Str("url", backendCfg.URL).Msg("Failed to create backend")
 			continue
@@ -233,21 +443,34 @@ func BalancerFactory(cfg *config.Config) (Balancer, error) {
 		backends = append(backends, backend)
 	}
 
-	if len(backends) == 0 {
+	if len(backends) == 0 && !cfg.Discovery.Enabled() {
 		log.Error().Msg("No valid backends configured")
 		return nil, ErrNoValidBackends
 	}
 
-	switch cfg.Balancer.Algorithm {
+	return NewBalancer(cfg.Balancer.Algorithm, backends, Options(cfg)...), nil
+}
+
+func NewBalancer(algorithm string, backends []*Backend, opts ...Option) Balancer {
+	switch algorithm {
 	case "round_robin":
-		return NewRoundRobinBalancer(backends), nil
+		return NewRoundRobinBalancer(backends, opts...)
 	case "least_connections":
-		return NewLeastConnectionsBalancer(backends), nil
+		return NewLeastConnectionsBalancer(backends, opts...)
 	case "random":
-		return NewRandomBalancer(backends), nil
+		return NewRandomBalancer(backends, opts...)
 	default:
-		log.Warn().Str("algorithm", cfg.Balancer.Algorithm).Msg("Unknown balancing algorithm, using round_robin")
-		return NewRoundRobinBalancer(backends), nil
+		log.Warn().Str("algorithm", algorithm).Msg("Unknown balancing algorithm, using round_robin")
+		return NewRoundRobinBalancer(backends, opts...)
+	}
+}
+
+func Options(cfg *config.Config) []Option {
+	return []Option{
+		WithSlowStart(cfg.Balancer.SlowStart),
+		WithOverprovisioningFactor(cfg.Balancer.OverprovisioningFactor),
+		WithZone(cfg.Balancer.Zone),
+		WithPanicThreshold(cfg.Balancer.PanicThreshold),
 	}
 }
 
@@ -271,7 +494,15 @@ func StartHealthChecks(ctx context.Context, balancer Balancer, cfg *config.Healt
 			backends := balancer.GetAllBackends()
 			for _, backend := range backends {
 				go func(b *Backend) {
+					start := time.Now()
 					isHealthy := healthChecker.Check(ctx, b)
+
+					result := "healthy"
+					if !isHealthy {
+						result = "unhealthy"
+					}
+					metrics.HealthCheckDuration.Observe(time.Since(start).Seconds(), b.URL.String(), result)
+
 					if isHealthy {
 						balancer.MarkBackendUp(b)
 					} else {
@@ -288,6 +519,14 @@ func (b *BaseBalancer) GetStatistics() map[string]BackendStats {
 	defer b.mutex.RUnlock()
 
 	stats := make(map[string]BackendStats)
+	now := time.Now()
+
+	tiers := buildTiers(b.backends, b.zone)
+	loads := tierLoads(tiers, b.overprovisioningFactor)
+	loadByPriority := make(map[int]float64, len(tiers))
+	for i, t := range tiers {
+		loadByPriority[t.priority] = loads[i] * 100
+	}
 
 	for _, backend := range b.backends {
 		burl := backend.URL.String()
@@ -299,13 +538,27 @@ func (b *BaseBalancer) GetStatistics() map[string]BackendStats {
 			failureRate = float64(failedReqs) / float64(totalReqs) * 100.0
 		}
 
+		var latencyStats *latency.Stats
+		if backend.Latency != nil {
+			s := backend.Latency.Stats()
+			latencyStats = &s
+		}
+
 		stats[burl] = BackendStats{
-			URL:           burl,
-			IsAlive:       backend.IsAvailable(),
-			ActiveConns:   backend.GetActiveConns(),
-			TotalRequests: totalReqs,
-			FailedReqs:    failedReqs,
-			FailureRate:   failureRate,
+			URL:             burl,
+			IsAlive:         backend.IsAvailable(),
+			IsDraining:      backend.Draining(),
+			IsDisabled:      !backend.Enabled(),
+			Weight:          backend.GetWeight(),
+			EffectiveWeight: b.EffectiveWeight(backend, now),
+			Priority:        backend.GetPriority(),
+			TierLoad:        loadByPriority[backend.GetPriority()],
+			Zone:            backend.GetZone(),
+			ActiveConns:     backend.GetActiveConns(),
+			TotalRequests:   totalReqs,
+			FailedReqs:      failedReqs,
+			FailureRate:     failureRate,
+			Latency:         latencyStats,
 		}
 	}
 
@@ -360,3 +360,436 @@ func TestStartHealthChecks(t *testing.T) {
 		t.Errorf("backend2 should not be available")
 	}
 }
+
+func TestSlowStart_Factor(t *testing.T) {
+	since := time.Now()
+
+	tests := []struct {
+		name    string
+		cfg     config.SlowStartConfig
+		elapsed time.Duration
+		want    float64
+	}{
+		{
+			name:    "Disabled",
+			cfg:     config.SlowStartConfig{Window: 0, Mode: SlowStartLinear, MinWeight: 0.1},
+			elapsed: 0,
+			want:    1,
+		},
+		{
+			name:    "Linear start",
+			cfg:     config.SlowStartConfig{Window: 10 * time.Second, Mode: SlowStartLinear, MinWeight: 0.1},
+			elapsed: 0,
+			want:    0.1,
+		},
+		{
+			name:    "Linear half",
+			cfg:     config.SlowStartConfig{Window: 10 * time.Second, Mode: SlowStartLinear, MinWeight: 0.2},
+			elapsed: 5 * time.Second,
+			want:    0.6,
+		},
+		{
+			name:    "Exponential half",
+			cfg:     config.SlowStartConfig{Window: 10 * time.Second, Mode: SlowStartExponential, MinWeight: 0.25},
+			elapsed: 5 * time.Second,
+			want:    0.5,
+		},
+		{
+			name:    "Window passed",
+			cfg:     config.SlowStartConfig{Window: 10 * time.Second, Mode: SlowStartExponential, MinWeight: 0.1},
+			elapsed: 11 * time.Second,
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newSlowStart(tt.cfg).factor(since, since.Add(tt.elapsed))
+			if got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("factor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundRobinBalancer_SlowStart(t *testing.T) {
+	backend1, _ := NewBackend("http://example1.com")
+	backend2, _ := NewBackend("http://example2.com")
+
+	balancer := NewRoundRobinBalancer([]*Backend{backend1, backend2}, WithSlowStart(config.SlowStartConfig{
+		Window:    time.Hour,
+		Mode:      SlowStartLinear,
+		MinWeight: 0.1,
+	}))
+
+	backend1.AliveSince.Store(time.Now().Add(-2 * time.Hour))
+
+	counts := make(map[string]int)
+	for i := 0; i < 110; i++ {
+		backend, err := balancer.NextBackend()
+		if err != nil {
+			t.Fatalf("NextBackend() error = %v", err)
+		}
+		counts[backend.URL.String()]++
+	}
+
+	if counts["http://example2.com"] > 15 {
+		t.Errorf("backend in slow start got %d of 110 requests, want about 10", counts["http://example2.com"])
+	}
+}
+
+func TestRoundRobinBalancer_CandidateSwitch(t *testing.T) {
+	var backends []*Backend
+	for _, u := range []string{"http://example1.com", "http://example2.com", "http://example3.com"} {
+		backend, _ := NewBackend(u)
+		backends = append(backends, backend)
+	}
+
+	balancer := NewRoundRobinBalancer(backends)
+	now := time.Now()
+
+	// Между выборами из всех бэкендов набор кандидатов сужается до одного,
+	// как при переключении между локальной зоной и всем уровнем.
+	counts := make(map[*Backend]int)
+	for i := 0; i < 30; i++ {
+		counts[balancer.pick(backends, now)]++
+		balancer.pick(backends[:1], now)
+	}
+
+	for _, backend := range backends {
+		if counts[backend] != 10 {
+			t.Errorf("backend %s picked %d of 30 times, want 10", backend.URL, counts[backend])
+		}
+	}
+
+	balancer.RemoveBackend(backends[2])
+	if _, ok := balancer.current[backends[2]]; ok {
+		t.Error("RemoveBackend() should drop the current weight of the removed backend")
+	}
+	if len(balancer.current) != 2 {
+		t.Errorf("current weights = %d, want 2 for remaining backends", len(balancer.current))
+	}
+}
+
+func TestBackend_MarkUpResetsAliveSince(t *testing.T) {
+	backend, _ := NewBackend("http://example.com")
+	old := time.Now().Add(-time.Hour)
+	backend.AliveSince.Store(old)
+
+	backend.MarkUp()
+	if !backend.GetAliveSince().Equal(old) {
+		t.Errorf("MarkUp() on alive backend should not reset AliveSince")
+	}
+
+	backend.MarkDown()
+	backend.MarkUp()
+	if !backend.GetAliveSince().After(old) {
+		t.Errorf("MarkUp() after MarkDown() should reset AliveSince")
+	}
+}
+
+func TestBaseBalancer_DrainingBackend(t *testing.T) {
+	backend1, _ := NewBackend("http://example1.com")
+	backend2, _ := NewBackend("http://example2.com")
+
+	balancer := NewRoundRobinBalancer([]*Backend{backend1, backend2})
+
+	backend2.StartDraining()
+
+	healthy := balancer.GetHealthyBackends()
+	if len(healthy) != 1 || healthy[0] != backend1 {
+		t.Errorf("GetHealthyBackends() should exclude draining backend, got %d backends", len(healthy))
+	}
+
+	stats := balancer.GetStatistics()
+	if stat, ok := stats["http://example2.com"]; !ok || !stat.IsDraining {
+		t.Errorf("GetStatistics() should report draining backend")
+	}
+
+	backend2.StopDraining()
+	if len(balancer.GetHealthyBackends()) != 2 {
+		t.Errorf("GetHealthyBackends() should include backend after StopDraining()")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	backend1, _ := NewBackend("http://example1.com")
+	backend2, _ := NewBackend("http://example2.com")
+	backend1.TotalRequests.Store(42)
+
+	balancer := NewRoundRobinBalancer([]*Backend{backend1, backend2})
+
+	added, removed := Reconcile(balancer, []config.BackendConfig{
+		{URL: "http://example1.com", Weight: 3},
+		{URL: "http://example3.com"},
+	})
+
+	if len(added) != 1 || added[0].URL.String() != "http://example3.com" {
+		t.Errorf("Reconcile() added = %v, want [http://example3.com]", added)
+	}
+	if len(removed) != 1 || removed[0] != backend2 {
+		t.Errorf("Reconcile() removed = %v, want [http://example2.com]", removed)
+	}
+
+	all := balancer.GetAllBackends()
+	if len(all) != 2 {
+		t.Fatalf("GetAllBackends() len = %v, want 2", len(all))
+	}
+	if all[0] != backend1 || backend1.TotalRequests.Load() != 42 {
+		t.Errorf("Reconcile() should keep existing backend and its statistics")
+	}
+	if backend1.GetWeight() != 3 {
+		t.Errorf("Reconcile() should update weight of existing backend, got %d", backend1.GetWeight())
+	}
+}
+
+func TestTierLoads(t *testing.T) {
+	newTier := func(priority, eligible, healthy int) tier {
+		tr := tier{priority: priority, eligible: eligible}
+		for i := 0; i < healthy; i++ {
+			tr.healthy = append(tr.healthy, &Backend{})
+		}
+		return tr
+	}
+
+	tests := []struct {
+		name  string
+		tiers []tier
+		want  []float64
+	}{
+		{
+			name:  "Primary fully healthy",
+			tiers: []tier{newTier(0, 4, 4), newTier(1, 4, 4)},
+			want:  []float64{1, 0},
+		},
+		{
+			name:  "Primary degraded within overprovisioning",
+			tiers: []tier{newTier(0, 10, 8), newTier(1, 4, 4)},
+			want:  []float64{1, 0},
+		},
+		{
+			name:  "Primary degraded below threshold spills over",
+			tiers: []tier{newTier(0, 10, 5), newTier(1, 4, 4)},
+			want:  []float64{0.7, 0.3},
+		},
+		{
+			name:  "Primary down",
+			tiers: []tier{newTier(0, 4, 0), newTier(1, 4, 4)},
+			want:  []float64{0, 1},
+		},
+		{
+			name:  "Not enough capacity anywhere is normalized",
+			tiers: []tier{newTier(0, 10, 2), newTier(1, 10, 3)},
+			want:  []float64{0.4, 0.6},
+		},
+		{
+			name:  "Nothing healthy",
+			tiers: []tier{newTier(0, 2, 0), newTier(1, 2, 0)},
+			want:  []float64{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tierLoads(tt.tiers, DefaultOverprovisioningFactor)
+			for i := range tt.want {
+				if got[i] < tt.want[i]-1e-9 || got[i] > tt.want[i]+1e-9 {
+					t.Errorf("tierLoads() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBaseBalancer_PriorityFailover(t *testing.T) {
+	primary, _ := NewBackendFromConfig(config.BackendConfig{URL: "http://primary.com", Priority: 0})
+	backup, _ := NewBackendFromConfig(config.BackendConfig{URL: "http://backup.com", Priority: 1})
+
+	balancer := NewRoundRobinBalancer([]*Backend{primary, backup})
+
+	for i := 0; i < 10; i++ {
+		backend, err := balancer.NextBackend()
+		if err != nil {
+			t.Fatalf("NextBackend() error = %v", err)
+		}
+		if backend != primary {
+			t.Fatalf("NextBackend() should use primary tier while it is healthy, got %s", backend.URL)
+		}
+	}
+
+	primary.MarkDown()
+
+	backend, err := balancer.NextBackend()
+	if err != nil {
+		t.Fatalf("NextBackend() error = %v", err)
+	}
+	if backend != backup {
+		t.Errorf("NextBackend() should fail over to backup tier, got %s", backend.URL)
+	}
+
+	stats := balancer.GetStatistics()
+	if stats["http://backup.com"].TierLoad != 100 || stats["http://primary.com"].TierLoad != 0 {
+		t.Errorf("GetStatistics() tier load = primary %v, backup %v", stats["http://primary.com"].TierLoad, stats["http://backup.com"].TierLoad)
+	}
+}
+
+func TestBaseBalancer_ZoneAware(t *testing.T) {
+	local, _ := NewBackendFromConfig(config.BackendConfig{URL: "http://local.com", Zone: "a"})
+	remote, _ := NewBackendFromConfig(config.BackendConfig{URL: "http://remote.com", Zone: "b"})
+
+	balancers := map[string]Balancer{
+		"round_robin":       NewRoundRobinBalancer([]*Backend{local, remote}, WithZone("a")),
+		"least_connections": NewLeastConnectionsBalancer([]*Backend{local, remote}, WithZone("a")),
+		"random":            NewRandomBalancer([]*Backend{local, remote}, WithZone("a")),
+	}
+
+	for name, balancer := range balancers {
+		t.Run(name, func(t *testing.T) {
+			local.MarkUp()
+			for i := 0; i < 10; i++ {
+				backend, err := balancer.NextBackend()
+				if err != nil {
+					t.Fatalf("NextBackend() error = %v", err)
+				}
+				if backend != local {
+					t.Fatalf("NextBackend() should prefer same-zone backend, got %s", backend.URL)
+				}
+			}
+
+			local.MarkDown()
+			backend, err := balancer.NextBackend()
+			if err != nil {
+				t.Fatalf("NextBackend() error = %v", err)
+			}
+			if backend != remote {
+				t.Errorf("NextBackend() should fall back to other zones, got %s", backend.URL)
+			}
+		})
+	}
+}
+
+func TestPickZone(t *testing.T) {
+	local1, _ := NewBackend("http://local1.com")
+	local2, _ := NewBackend("http://local2.com")
+	remote, _ := NewBackend("http://remote.com")
+
+	partial := &tier{
+		eligible:      4,
+		healthy:       []*Backend{local1, remote},
+		localEligible: 3,
+		local:         []*Backend{local1},
+	}
+
+	var spilled int
+	for i := 0; i < 1000; i++ {
+		if len(pickZone(partial, DefaultOverprovisioningFactor)) != 1 {
+			spilled++
+		}
+	}
+	// Локальная ёмкость 1.4/3 ≈ 47%, остальное уходит во все зоны.
+	if spilled < 400 || spilled > 660 {
+		t.Errorf("pickZone() spilled %d of 1000, want about 530", spilled)
+	}
+
+	full := &tier{
+		eligible:      3,
+		healthy:       []*Backend{local1, local2, remote},
+		localEligible: 2,
+		local:         []*Backend{local1, local2},
+	}
+	for i := 0; i < 100; i++ {
+		if got := pickZone(full, DefaultOverprovisioningFactor); len(got) != 2 {
+			t.Fatalf("pickZone() = %d backends, want only local ones", len(got))
+		}
+	}
+}
+
+func TestBaseBalancer_PanicThreshold(t *testing.T) {
+	var backends []*Backend
+	for _, u := range []string{"http://backend1.com", "http://backend2.com", "http://backend3.com", "http://backend4.com"} {
+		backend, _ := NewBackend(u)
+		backends = append(backends, backend)
+	}
+
+	balancer := NewRoundRobinBalancer(backends, WithPanicThreshold(0.5))
+
+	backends[0].MarkDown()
+	if got := len(balancer.candidates()); got != 3 {
+		t.Fatalf("candidates() = %d backends, want 3 healthy above threshold", got)
+	}
+
+	backends[1].MarkDown()
+	backends[2].MarkDown()
+	if got := len(balancer.candidates()); got != 4 {
+		t.Fatalf("candidates() = %d backends, want all 4 in panic mode", got)
+	}
+
+	status := balancer.PanicStatus()
+	if !status.Active || status.Entered != 1 || status.Exited != 0 {
+		t.Errorf("PanicStatus() = %+v, want active with one entry", status)
+	}
+
+	backends[3].MarkDown()
+	if _, err := balancer.NextBackend(); err != nil {
+		t.Errorf("NextBackend() in panic mode error = %v, want any backend", err)
+	}
+
+	for _, backend := range backends {
+		backend.MarkUp()
+	}
+	if got := len(balancer.candidates()); got != 4 {
+		t.Fatalf("candidates() = %d backends, want 4", got)
+	}
+
+	status = balancer.PanicStatus()
+	if status.Active || status.Entered != 1 || status.Exited != 1 {
+		t.Errorf("PanicStatus() = %+v, want inactive with one exit", status)
+	}
+}
+
+func TestBaseBalancer_PanicThresholdDisabled(t *testing.T) {
+	backend, _ := NewBackend("http://backend.com")
+	backend.MarkDown()
+
+	balancer := NewRoundRobinBalancer([]*Backend{backend})
+	if _, err := balancer.NextBackend(); err != ErrNoBackends {
+		t.Errorf("NextBackend() error = %v, want ErrNoBackends without panic threshold", err)
+	}
+	if balancer.PanicStatus().Active {
+		t.Errorf("PanicStatus() should be inactive without panic threshold")
+	}
+}
+
+func TestDynamicBalancer_SwapKeepsPanicState(t *testing.T) {
+	var backends []*Backend
+	for _, u := range []string{"http://backend1.com", "http://backend2.com"} {
+		backend, _ := NewBackend(u)
+		backends = append(backends, backend)
+	}
+
+	dynamic := NewDynamicBalancer(NewRoundRobinBalancer(backends, WithPanicThreshold(0.6)))
+
+	// Вход, выход и повторный вход в режим паники до замены.
+	for _, markDown := range []bool{true, false, true} {
+		if markDown {
+			backends[0].MarkDown()
+		} else {
+			backends[0].MarkUp()
+		}
+		dynamic.PanicStatus()
+	}
+
+	dynamic.Swap(NewLeastConnectionsBalancer(dynamic.GetAllBackends(), WithPanicThreshold(0.6)))
+
+	status := dynamic.PanicStatus()
+	if !status.Active || status.Entered != 2 || status.Exited != 1 {
+		t.Errorf("PanicStatus() after swap = %+v, want active with 2 entries and 1 exit", status)
+	}
+
+	backends[0].MarkUp()
+	status = dynamic.PanicStatus()
+	if status.Active || status.Entered != 2 || status.Exited != 2 {
+		t.Errorf("PanicStatus() = %+v, want inactive with 2 exits", status)
+	}
+}
@@ -0,0 +1,72 @@
+package balancer
+
+import (
+	"sync/atomic"
+)
+
+// DynamicBalancer позволяет атомарно подменить алгоритм балансировки
+// без пересоздания прокси и обработчиков, которые на него ссылаются.
+type DynamicBalancer struct {
+	current atomic.Pointer[Balancer]
+}
+
+func NewDynamicBalancer(initial Balancer) *DynamicBalancer {
+	d := &DynamicBalancer{}
+	d.current.Store(&initial)
+	return d
+}
+
+// Swap подменяет балансировщик. Состояние режима паники переходит к новому
+// балансировщику: счётчики переходов продолжаются, а не начинаются с нуля.
+func (d *DynamicBalancer) Swap(next Balancer) Balancer {
+	prev, ok := d.Current().(panicHolder)
+	if nextHolder, nextOK := next.(panicHolder); ok && nextOK {
+		nextHolder.setPanicState(prev.panicState())
+	}
+
+	return *d.current.Swap(&next)
+}
+
+func (d *DynamicBalancer) Current() Balancer {
+	return *d.current.Load()
+}
+
+func (d *DynamicBalancer) NextBackend() (*Backend, error) {
+	return d.Current().NextBackend()
+}
+
+func (d *DynamicBalancer) RegisterBackend(backend *Backend) {
+	d.Current().RegisterBackend(backend)
+}
+
+func (d *DynamicBalancer) RemoveBackend(backend *Backend) {
+	d.Current().RemoveBackend(backend)
+}
+
+func (d *DynamicBalancer) MarkBackendDown(backend *Backend) {
+	d.Current().MarkBackendDown(backend)
+}
+
+func (d *DynamicBalancer) MarkBackendUp(backend *Backend) {
+	d.Current().MarkBackendUp(backend)
+}
+
+func (d *DynamicBalancer) GetHealthyBackends() []*Backend {
+	return d.Current().GetHealthyBackends()
+}
+
+func (d *DynamicBalancer) GetAllBackends() []*Backend {
+	return d.Current().GetAllBackends()
+}
+
+func (d *DynamicBalancer) Name() string {
+	return d.Current().Name()
+}
+
+func (d *DynamicBalancer) GetStatistics() map[string]BackendStats {
+	return d.Current().GetStatistics()
+}
+
+func (d *DynamicBalancer) PanicStatus() PanicStatus {
+	return d.Current().PanicStatus()
+}
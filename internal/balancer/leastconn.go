@@ -1,34 +1,42 @@
 package balancer
 
 import (
-	"github.com/rs/zerolog/log"
+	"time"
 )
 
 type LeastConnectionsBalancer struct {
 	*BaseBalancer
 }
 
-func NewLeastConnectionsBalancer(backends []*Backend) *LeastConnectionsBalancer {
+func NewLeastConnectionsBalancer(backends []*Backend, opts ...Option) *LeastConnectionsBalancer {
 	return &LeastConnectionsBalancer{
-		BaseBalancer: NewBaseBalancer(backends),
+		BaseBalancer: NewBaseBalancer(backends, opts...),
 	}
 }
 
 func (lb *LeastConnectionsBalancer) NextBackend() (*Backend, error) {
-	healthy := lb.GetHealthyBackends()
+	healthy := lb.candidates()
 
 	if len(healthy) == 0 {
 		log.Warn().Msg("No healthy backends available")
 		return nil, ErrNoBackends
 	}
 
+	now := time.Now()
+
+	// Нагрузка нормируется на эффективный вес, чтобы бэкенды в slow start
+	// получали меньшую долю соединений.
+	score := func(b *Backend) float64 {
+		return float64(b.GetActiveConns()+1) / lb.EffectiveWeight(b, now)
+	}
+
 	var minIdx int
-	minConn := healthy[0].GetActiveConns()
+	minScore := score(healthy[0])
 
 	for i := 1; i < len(healthy); i++ {
-		conn := healthy[i].GetActiveConns()
-		if conn < minConn {
-			minConn = conn
+		s := score(healthy[i])
+		if s < minScore {
+			minScore = s
 			minIdx = i
 		}
 	}
@@ -36,7 +44,7 @@ func (lb *LeastConnectionsBalancer) NextBackend() (*Backend, error) {
 	backend := healthy[minIdx]
 	log.Debug().
 		Str("backend", backend.URL.String()).
-		Int32("active_connections", minConn).
+		Int32("active_connections", backend.GetActiveConns()).
 		Msg("Selected backend using least-connections")
 
 	return backend, nil
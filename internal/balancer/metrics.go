@@ -0,0 +1,57 @@
+package balancer
+
+import (
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
+)
+
+// RegisterMetrics публикует состояние бэкендов балансировщика на /metrics.
+// Значения вычисляются при каждом чтении метрик.
+func RegisterMetrics(registry *metrics.Registry, lb Balancer) {
+	registry.NewGaugeFunc(
+		"lb_backend_up",
+		"Whether the backend passes health checks (1) or not (0).",
+		func(emit func(value float64, labelValues ...string)) {
+			for _, backend := range lb.GetAllBackends() {
+				emit(boolValue(backend.IsAvailable()), backend.URL.String())
+			}
+		},
+		"backend",
+	)
+
+	registry.NewGaugeFunc(
+		"lb_backend_in_rotation",
+		"Whether the backend is enabled and not draining (1) or not (0).",
+		func(emit func(value float64, labelValues ...string)) {
+			for _, backend := range lb.GetAllBackends() {
+				emit(boolValue(backend.Enabled() && !backend.Draining()), backend.URL.String())
+			}
+		},
+		"backend",
+	)
+
+	registry.NewGaugeFunc(
+		"lb_backend_active_connections",
+		"Number of in-flight requests to the backend.",
+		func(emit func(value float64, labelValues ...string)) {
+			for _, backend := range lb.GetAllBackends() {
+				emit(float64(backend.GetActiveConns()), backend.URL.String())
+			}
+		},
+		"backend",
+	)
+
+	registry.NewGaugeFunc(
+		"lb_balancer_panic_mode",
+		"Whether the balancer ignores backend health because too few backends are healthy.",
+		func(emit func(value float64, labelValues ...string)) {
+			emit(boolValue(lb.PanicStatus().Active))
+		},
+	)
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
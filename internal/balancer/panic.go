@@ -0,0 +1,90 @@
+package balancer
+
+import (
+	"sync/atomic"
+)
+
+type PanicStatus struct {
+	Active    bool    `json:"active"`
+	Threshold float64 `json:"threshold"`
+	Entered   int64   `json:"entered_total"`
+	Exited    int64   `json:"exited_total"`
+}
+
+type panicMode struct {
+	active  atomic.Bool
+	entered atomic.Int64
+	exited  atomic.Int64
+}
+
+// panicHolder - балансировщик с состоянием режима паники. DynamicBalancer
+// передаёт состояние новому балансировщику, чтобы счётчики переходов не
+// сбрасывались при смене алгоритма.
+type panicHolder interface {
+	panicState() *panicMode
+	setPanicState(state *panicMode)
+}
+
+func (b *BaseBalancer) panicState() *panicMode {
+	return b.panicMode
+}
+
+// setPanicState вызывается до того, как балансировщик начнёт принимать
+// запросы.
+func (b *BaseBalancer) setPanicState(state *panicMode) {
+	b.panicMode = state
+}
+
+// checkPanic определяет, нужно ли игнорировать результаты проверок здоровья:
+// если доля здоровых бэкендов ниже порога, нагрузка распределяется на все
+// бэкенды, чтобы не перегрузить оставшиеся и не отвечать 503.
+func (b *BaseBalancer) checkPanic(tiers []tier) bool {
+	if b.panicThreshold <= 0 {
+		return false
+	}
+
+	var eligible, healthy int
+	for _, t := range tiers {
+		eligible += t.eligible
+		healthy += len(t.healthy)
+	}
+	if eligible == 0 {
+		return false
+	}
+
+	fraction := float64(healthy) / float64(eligible)
+	active := fraction < b.panicThreshold
+
+	if b.panicMode.active.Swap(active) != active {
+		if active {
+			b.panicMode.entered.Add(1)
+			log.Warn().
+				Int("healthy", healthy).
+				Int("total", eligible).
+				Float64("threshold", b.panicThreshold).
+				Msg("Entering panic mode, ignoring backend health")
+		} else {
+			b.panicMode.exited.Add(1)
+			log.Info().
+				Int("healthy", healthy).
+				Int("total", eligible).
+				Float64("threshold", b.panicThreshold).
+				Msg("Leaving panic mode")
+		}
+	}
+
+	return active
+}
+
+func (b *BaseBalancer) PanicStatus() PanicStatus {
+	b.mutex.RLock()
+	b.checkPanic(buildTiers(b.backends, b.zone))
+	b.mutex.RUnlock()
+
+	return PanicStatus{
+		Active:    b.panicMode.active.Load(),
+		Threshold: b.panicThreshold,
+		Entered:   b.panicMode.entered.Load(),
+		Exited:    b.panicMode.exited.Load(),
+	}
+}
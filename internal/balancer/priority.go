@@ -0,0 +1,132 @@
+package balancer
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+const DefaultOverprovisioningFactor = 1.4
+
+type tier struct {
+	priority      int
+	eligible      int
+	backends      []*Backend
+	healthy       []*Backend
+	localEligible int
+	local         []*Backend
+}
+
+// buildTiers группирует бэкенды по приоритету. Отключённые и выводимые из
+// ротации бэкенды не учитываются в ёмкости уровня. Если задана зона
+// балансировщика, бэкенды той же зоны дополнительно учитываются как локальные.
+func buildTiers(backends []*Backend, zone string) []tier {
+	byPriority := make(map[int]*tier)
+	for _, backend := range backends {
+		if backend.Draining() || !backend.Enabled() {
+			continue
+		}
+
+		priority := backend.GetPriority()
+		t, ok := byPriority[priority]
+		if !ok {
+			t = &tier{priority: priority}
+			byPriority[priority] = t
+		}
+
+		isLocal := zone != "" && backend.GetZone() == zone
+
+		t.eligible++
+		t.backends = append(t.backends, backend)
+		if isLocal {
+			t.localEligible++
+		}
+		if backend.IsAvailable() {
+			t.healthy = append(t.healthy, backend)
+			if isLocal {
+				t.local = append(t.local, backend)
+			}
+		}
+	}
+
+	tiers := make([]tier, 0, len(byPriority))
+	for _, t := range byPriority {
+		tiers = append(tiers, *t)
+	}
+	slices.SortFunc(tiers, func(a, b tier) int {
+		return a.priority - b.priority
+	})
+
+	return tiers
+}
+
+// tierLoads распределяет трафик между уровнями так же, как Envoy: уровень
+// получает долю, пропорциональную доле здоровых бэкендов, умноженной на
+// overprovisioning factor, а недостающее переливается на следующие уровни.
+func tierLoads(tiers []tier, factor float64) []float64 {
+	health := make([]float64, len(tiers))
+	var total float64
+	for i, t := range tiers {
+		if t.eligible == 0 {
+			continue
+		}
+		health[i] = min(1, factor*float64(len(t.healthy))/float64(t.eligible))
+		total += health[i]
+	}
+
+	loads := make([]float64, len(tiers))
+	if total == 0 {
+		return loads
+	}
+
+	// Если суммарной ёмкости не хватает, доли нормируются, чтобы в сумме дать 100%.
+	if total < 1 {
+		for i := range health {
+			health[i] /= total
+		}
+	}
+
+	remaining := 1.0
+	for i := range tiers {
+		loads[i] = min(health[i], remaining)
+		remaining -= loads[i]
+	}
+
+	return loads
+}
+
+func pickTier(tiers []tier, loads []float64) *tier {
+	point := rand.Float64()
+	for i, load := range loads {
+		if load <= 0 {
+			continue
+		}
+		if point < load {
+			return &tiers[i]
+		}
+		point -= load
+	}
+
+	for i := len(loads) - 1; i >= 0; i-- {
+		if loads[i] > 0 {
+			return &tiers[i]
+		}
+	}
+
+	return nil
+}
+
+// pickZone оставляет в уровне только бэкенды своей зоны, пока их ёмкости
+// хватает с учётом overprovisioning factor. Иначе недостающая доля запросов
+// уходит во все зоны уровня.
+func pickZone(t *tier, factor float64) []*Backend {
+	if t.localEligible == 0 || len(t.local) == 0 {
+		return t.healthy
+	}
+
+	localLoad := min(1, factor*float64(len(t.local))/float64(t.localEligible))
+	if localLoad >= 1 || rand.Float64() < localLoad {
+		return t.local
+	}
+
+	return t.healthy
+}
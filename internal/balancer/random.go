@@ -1,10 +1,9 @@
 package balancer
 
 import (
+	"math/rand/v2"
 	"sync"
 	"time"
-
-	"github.com/rs/zerolog/log"
 )
 
 type RandomBalancer struct {
@@ -12,22 +11,37 @@ type RandomBalancer struct {
 	rnd sync.Pool
 }
 
-func NewRandomBalancer(backends []*Backend) *RandomBalancer {
+func NewRandomBalancer(backends []*Backend, opts ...Option) *RandomBalancer {
 	return &RandomBalancer{
-		BaseBalancer: NewBaseBalancer(backends),
+		BaseBalancer: NewBaseBalancer(backends, opts...),
 	}
 }
 
 func (rb *RandomBalancer) NextBackend() (*Backend, error) {
-	healthy := rb.GetHealthyBackends()
+	healthy := rb.candidates()
 
 	if len(healthy) == 0 {
 		log.Warn().Msg("No healthy backends available")
 		return nil, ErrNoBackends
 	}
 
-	idx := time.Now().UnixNano() % int64(len(healthy))
-	backend := healthy[idx]
+	now := time.Now()
+	weights := make([]float64, len(healthy))
+	var total float64
+	for i, backend := range healthy {
+		weights[i] = rb.EffectiveWeight(backend, now)
+		total += weights[i]
+	}
+
+	backend := healthy[len(healthy)-1]
+	point := rand.Float64() * total
+	for i, weight := range weights {
+		if point < weight {
+			backend = healthy[i]
+			break
+		}
+		point -= weight
+	}
 
 	log.Debug().Str("backend", backend.URL.String()).Msg("Selected backend using random algorithm")
 	return backend, nil
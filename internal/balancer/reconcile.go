@@ -0,0 +1,54 @@
+package balancer
+
+import (
+	"net/url"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+// Reconcile приводит набор бэкендов балансировщика к desired. Бэкенды,
+// которые остались в наборе, сохраняют свои объекты и статистику.
+func Reconcile(balancer Balancer, desired []config.BackendConfig) (added, removed []*Backend) {
+	existing := make(map[string]*Backend)
+	for _, backend := range balancer.GetAllBackends() {
+		existing[backend.URL.String()] = backend
+	}
+
+	seen := make(map[string]bool, len(desired))
+	for _, backendCfg := range desired {
+		u, err := url.Parse(backendCfg.URL)
+		if err != nil {
+			log.Error().Err(err).Str("url", backendCfg.URL).Msg("Failed to parse backend URL")
+			continue
+		}
+
+		key := u.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if backend, ok := existing[key]; ok {
+			backend.ApplyConfig(backendCfg)
+			continue
+		}
+
+		backend, err := NewBackendFromConfig(backendCfg)
+		if err != nil {
+			log.Error().Err(err).Str("url", backendCfg.URL).Msg("Failed to create backend")
+			continue
+		}
+
+		balancer.RegisterBackend(backend)
+		added = append(added, backend)
+	}
+
+	for key, backend := range existing {
+		if !seen[key] {
+			balancer.RemoveBackend(backend)
+			removed = append(removed, backend)
+		}
+	}
+
+	return added, removed
+}
@@ -1,37 +1,77 @@
 package balancer
 
 import (
-	"sync/atomic"
-
-	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
 )
 
 type RoundRobinBalancer struct {
 	*BaseBalancer
-	current atomic.Int64
+	mu      sync.Mutex
+	current map[*Backend]float64
 }
 
-func NewRoundRobinBalancer(backends []*Backend) *RoundRobinBalancer {
+func NewRoundRobinBalancer(backends []*Backend, opts ...Option) *RoundRobinBalancer {
 	return &RoundRobinBalancer{
-		BaseBalancer: NewBaseBalancer(backends),
+		BaseBalancer: NewBaseBalancer(backends, opts...),
+		current:      make(map[*Backend]float64),
 	}
 }
 
 func (rb *RoundRobinBalancer) NextBackend() (*Backend, error) {
-	healthy := rb.GetHealthyBackends()
+	healthy := rb.candidates()
 
 	if len(healthy) == 0 {
 		log.Warn().Msg("No healthy backends available")
 		return nil, ErrNoBackends
 	}
 
-	next := rb.current.Add(1) % int64(len(healthy))
-	backend := healthy[next]
+	backend := rb.pick(healthy, time.Now())
 
 	log.Debug().Str("backend", backend.URL.String()).Msg("Selected backend using round-robin")
 	return backend, nil
 }
 
+// pick реализует smooth weighted round-robin (как в nginx): при равных весах
+// бэкенды выбираются строго по кругу. Текущие веса бэкендов, не попавших в
+// набор кандидатов (другая зона или уровень приоритета), сохраняются, иначе
+// после каждого переключения набора выигрывал бы первый бэкенд в списке.
+func (rb *RoundRobinBalancer) pick(healthy []*Backend, now time.Time) *Backend {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	var best *Backend
+	var total float64
+
+	for _, backend := range healthy {
+		weight := rb.EffectiveWeight(backend, now)
+		total += weight
+		rb.current[backend] += weight
+
+		if best == nil || rb.current[backend] > rb.current[best] {
+			best = backend
+		}
+	}
+
+	rb.current[best] -= total
+
+	return best
+}
+
+// RemoveBackend убирает бэкенд из балансировщика вместе с его текущим весом.
+func (rb *RoundRobinBalancer) RemoveBackend(backend *Backend) {
+	rb.BaseBalancer.RemoveBackend(backend)
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	for b := range rb.current {
+		if b.URL.String() == backend.URL.String() {
+			delete(rb.current, b)
+		}
+	}
+}
+
 func (rb *RoundRobinBalancer) Name() string {
 	return "round_robin"
 }
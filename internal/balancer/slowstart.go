@@ -0,0 +1,60 @@
+package balancer
+
+import (
+	"math"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+const (
+	SlowStartLinear      = "linear"
+	SlowStartExponential = "exponential"
+)
+
+type slowStart struct {
+	window    time.Duration
+	mode      string
+	minWeight float64
+}
+
+func newSlowStart(cfg config.SlowStartConfig) slowStart {
+	minWeight := cfg.MinWeight
+	if minWeight <= 0 || minWeight > 1 {
+		minWeight = 0.1
+	}
+
+	return slowStart{
+		window:    cfg.Window,
+		mode:      cfg.Mode,
+		minWeight: minWeight,
+	}
+}
+
+func (s slowStart) enabled() bool {
+	return s.window > 0
+}
+
+// factor возвращает долю полного веса бэкенда, который поднялся в момент since.
+func (s slowStart) factor(since, now time.Time) float64 {
+	if !s.enabled() {
+		return 1
+	}
+
+	elapsed := now.Sub(since)
+	if elapsed >= s.window {
+		return 1
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	progress := float64(elapsed) / float64(s.window)
+
+	switch s.mode {
+	case SlowStartExponential:
+		return math.Pow(s.minWeight, 1-progress)
+	default:
+		return s.minWeight + (1-s.minWeight)*progress
+	}
+}
@@ -0,0 +1,142 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+type DNSProvider struct {
+	cfg      config.DNSDiscoveryConfig
+	resolver Resolver
+}
+
+func NewDNSProvider(cfg config.DNSDiscoveryConfig, resolver Resolver) *DNSProvider {
+	return &DNSProvider{
+		cfg:      cfg,
+		resolver: resolver,
+	}
+}
+
+func (s *DNSProvider) Name() string {
+	return "dns:" + s.cfg.Type + ":" + s.cfg.Name
+}
+
+func (s *DNSProvider) Run(ctx context.Context, update UpdateFunc) {
+	log.Info().
+		Str("source", s.Name()).
+		Dur("interval", s.cfg.Interval).
+		Msg("Starting DNS discovery")
+
+	for {
+		next := s.refresh(ctx, update)
+
+		timer := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info().Str("source", s.Name()).Msg("Stopping DNS discovery")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh обновляет набор бэкендов и возвращает время до следующего запроса:
+// интервал из конфигурации, но не дольше минимального TTL записей.
+func (s *DNSProvider) refresh(ctx context.Context, update UpdateFunc) time.Duration {
+	backends, ttl, err := s.Resolve(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("source", s.Name()).Msg("DNS discovery failed, keeping previous backends")
+		return s.cfg.Interval
+	}
+
+	if len(backends) == 0 {
+		log.Warn().Str("source", s.Name()).Msg("DNS discovery returned no records, keeping previous backends")
+		return s.cfg.Interval
+	}
+
+	update(backends)
+
+	next := s.cfg.Interval
+	if ttl > 0 && ttl < next {
+		next = max(ttl, s.cfg.MinInterval)
+	}
+
+	return next
+}
+
+func (s *DNSProvider) Resolve(ctx context.Context) ([]config.BackendConfig, time.Duration, error) {
+	switch s.cfg.Type {
+	case "srv":
+		return s.resolveSRV(ctx)
+	default:
+		return s.resolveHost(ctx)
+	}
+}
+
+func (s *DNSProvider) resolveHost(ctx context.Context) ([]config.BackendConfig, time.Duration, error) {
+	records, err := s.resolver.LookupHost(ctx, s.cfg.Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to resolve %s: %w", s.cfg.Name, err)
+	}
+
+	var ttl time.Duration
+	backends := make([]config.BackendConfig, 0, len(records))
+	for _, record := range records {
+		ttl = minTTL(ttl, record.TTL)
+		backends = append(backends, config.BackendConfig{
+			URL:      s.backendURL(record.IP.String(), s.cfg.Port),
+			Weight:   s.cfg.Weight,
+			Priority: s.cfg.Priority,
+			Zone:     s.cfg.Zone,
+		})
+	}
+
+	return backends, ttl, nil
+}
+
+// resolveSRV переносит приоритет SRV-записи в приоритет бэкенда, а вес -
+// в вес бэкенда, так что резервные записи получают трафик только при
+// деградации основных.
+func (s *DNSProvider) resolveSRV(ctx context.Context) ([]config.BackendConfig, time.Duration, error) {
+	records, err := s.resolver.LookupSRV(ctx, s.cfg.Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to resolve SRV %s: %w", s.cfg.Name, err)
+	}
+
+	var ttl time.Duration
+	backends := make([]config.BackendConfig, 0, len(records))
+	for _, record := range records {
+		ttl = minTTL(ttl, record.TTL)
+		backends = append(backends, config.BackendConfig{
+			URL:      s.backendURL(strings.TrimSuffix(record.Target, "."), int(record.Port)),
+			Weight:   max(int(record.Weight), 1),
+			Priority: int(record.Priority),
+			Zone:     s.cfg.Zone,
+		})
+	}
+
+	return backends, ttl, nil
+}
+
+func (s *DNSProvider) backendURL(host string, port int) string {
+	return s.cfg.Scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func minTTL(current, ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return current
+	}
+	if current <= 0 || ttl < current {
+		return ttl
+	}
+	return current
+}
@@ -0,0 +1,181 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/admin"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+)
+
+type fakeResolver struct {
+	hosts map[string][]HostRecord
+	srvs  map[string][]SRVRecord
+	err   error
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, name string) ([]HostRecord, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.hosts[name], nil
+}
+
+func (f *fakeResolver) LookupSRV(ctx context.Context, name string) ([]SRVRecord, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.srvs[name], nil
+}
+
+func newTestManager(t *testing.T, static []config.BackendConfig) (*Manager, balancer.Balancer) {
+	state, err := admin.NewStateStore("")
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+
+	lb := balancer.NewDynamicBalancer(balancer.NewRoundRobinBalancer(nil))
+	manager := NewManager(lb, state, static)
+	manager.Sync()
+
+	return manager, lb
+}
+
+func backendURLs(lb balancer.Balancer) map[string]*balancer.Backend {
+	result := make(map[string]*balancer.Backend)
+	for _, backend := range lb.GetAllBackends() {
+		result[backend.URL.String()] = backend
+	}
+	return result
+}
+
+func TestDNSProvider_RefreshA(t *testing.T) {
+	resolver := &fakeResolver{
+		hosts: map[string][]HostRecord{
+			"api.internal": {
+				{IP: net.ParseIP("10.0.0.1"), TTL: 10 * time.Second},
+				{IP: net.ParseIP("fd00::2"), TTL: 5 * time.Second},
+			},
+		},
+	}
+
+	manager, lb := newTestManager(t, []config.BackendConfig{{URL: "http://static"}})
+
+	source := NewDNSProvider(config.DNSDiscoveryConfig{
+		Name:        "api.internal",
+		Type:        "a",
+		Port:        8080,
+		Scheme:      "http",
+		Interval:    30 * time.Second,
+		MinInterval: time.Second,
+	}, resolver)
+	update := func(backends []config.BackendConfig) {
+		manager.Update(source.Name(), backends)
+	}
+
+	next := source.refresh(context.Background(), update)
+	if next != 5*time.Second {
+		t.Errorf("refresh() next = %v, want 5s from the lowest TTL", next)
+	}
+
+	backends := backendURLs(lb)
+	for _, want := range []string{"http://static", "http://10.0.0.1:8080", "http://[fd00::2]:8080"} {
+		if _, ok := backends[want]; !ok {
+			t.Errorf("backend %s not registered, got %v", want, backends)
+		}
+	}
+
+	kept := backends["http://10.0.0.1:8080"]
+	kept.TotalRequests.Store(7)
+
+	resolver.hosts["api.internal"] = []HostRecord{{IP: net.ParseIP("10.0.0.1")}}
+	if next := source.refresh(context.Background(), update); next != 30*time.Second {
+		t.Errorf("refresh() without TTL next = %v, want interval 30s", next)
+	}
+
+	backends = backendURLs(lb)
+	if len(backends) != 2 {
+		t.Errorf("after refresh backends = %v, want static and 10.0.0.1", backends)
+	}
+	if backends["http://10.0.0.1:8080"] != kept || kept.TotalRequests.Load() != 7 {
+		t.Errorf("refresh() should keep unchanged backend and its statistics")
+	}
+}
+
+func TestDNSProvider_RefreshSRV(t *testing.T) {
+	resolver := &fakeResolver{
+		srvs: map[string][]SRVRecord{
+			"_http._tcp.api.internal": {
+				{Target: "a.internal.", Port: 80, Priority: 10, Weight: 3},
+				{Target: "b.internal.", Port: 81, Priority: 10, Weight: 0},
+				{Target: "backup.internal.", Port: 80, Priority: 20, Weight: 1},
+			},
+		},
+	}
+
+	manager, lb := newTestManager(t, nil)
+
+	source := NewDNSProvider(config.DNSDiscoveryConfig{
+		Name:        "_http._tcp.api.internal",
+		Type:        "srv",
+		Scheme:      "http",
+		Interval:    30 * time.Second,
+		MinInterval: time.Second,
+	}, resolver)
+	update := func(backends []config.BackendConfig) {
+		manager.Update(source.Name(), backends)
+	}
+
+	source.refresh(context.Background(), update)
+
+	backends := backendURLs(lb)
+	if len(backends) != 3 {
+		t.Fatalf("backends = %v, want all SRV records", backends)
+	}
+	if b := backends["http://a.internal:80"]; b == nil || b.GetWeight() != 3 {
+		t.Errorf("SRV weight should map to backend weight")
+	}
+	if b := backends["http://b.internal:81"]; b == nil || b.GetWeight() != 1 {
+		t.Errorf("SRV weight 0 should map to backend weight 1")
+	}
+	if b := backends["http://backup.internal:80"]; b == nil || b.GetPriority() != 20 {
+		t.Errorf("SRV priority should map to backend priority")
+	}
+}
+
+func TestDNSProvider_RefreshErrorKeepsBackends(t *testing.T) {
+	resolver := &fakeResolver{
+		hosts: map[string][]HostRecord{
+			"api.internal": {{IP: net.ParseIP("10.0.0.1")}},
+		},
+	}
+
+	manager, lb := newTestManager(t, nil)
+
+	source := NewDNSProvider(config.DNSDiscoveryConfig{
+		Name:        "api.internal",
+		Type:        "a",
+		Port:        80,
+		Scheme:      "http",
+		Interval:    time.Minute,
+		MinInterval: time.Second,
+	}, resolver)
+	update := func(backends []config.BackendConfig) {
+		manager.Update(source.Name(), backends)
+	}
+
+	source.refresh(context.Background(), update)
+
+	resolver.err = errors.New("server failure")
+	if next := source.refresh(context.Background(), update); next != time.Minute {
+		t.Errorf("refresh() on error next = %v, want interval", next)
+	}
+
+	if len(lb.GetAllBackends()) != 1 {
+		t.Errorf("refresh() on error should keep previous backends")
+	}
+}
@@ -0,0 +1,136 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+const fileDebounce = 200 * time.Millisecond
+
+type FileProvider struct {
+	cfg config.FileDiscoveryConfig
+}
+
+func NewFileProvider(cfg config.FileDiscoveryConfig) *FileProvider {
+	return &FileProvider{
+		cfg: cfg,
+	}
+}
+
+func (p *FileProvider) Name() string {
+	return "file:" + p.cfg.Path
+}
+
+// Run перечитывает файл при его изменении, а также раз в интервал на случай,
+// если события файловой системы недоступны (например, на сетевых томах).
+func (p *FileProvider) Run(ctx context.Context, update UpdateFunc) {
+	path, err := filepath.Abs(p.cfg.Path)
+	if err != nil {
+		log.Error().Err(err).Str("source", p.Name()).Msg("Invalid targets file path")
+		return
+	}
+
+	var events <-chan fsnotify.Event
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		if err = watcher.Add(filepath.Dir(path)); err == nil {
+			events = watcher.Events
+		}
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("source", p.Name()).Msg("File watching unavailable, falling back to polling")
+	}
+
+	log.Info().
+		Str("source", p.Name()).
+		Dur("interval", p.cfg.Interval).
+		Msg("Starting file discovery")
+
+	var lastModified time.Time
+	refresh := func(force bool) {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Error().Err(err).Str("source", p.Name()).Msg("File discovery failed, keeping previous backends")
+			return
+		}
+		if !force && info.ModTime().Equal(lastModified) {
+			return
+		}
+
+		backends, err := p.Load(path)
+		if err != nil {
+			log.Error().Err(err).Str("source", p.Name()).Msg("File discovery failed, keeping previous backends")
+			return
+		}
+
+		lastModified = info.ModTime()
+		update(backends)
+	}
+
+	refresh(true)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	var debounce *time.Timer
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			if debounce != nil {
+				debounce.Stop()
+			}
+			log.Info().Str("source", p.Name()).Msg("Stopping file discovery")
+			return
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if filepath.Clean(event.Name) != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.NewTimer(fileDebounce)
+			fire = debounce.C
+		case <-fire:
+			fire = nil
+			refresh(true)
+		case <-ticker.C:
+			refresh(false)
+		}
+	}
+}
+
+func (p *FileProvider) Load(path string) ([]config.BackendConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := "json"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	}
+
+	backends, err := parseTargetGroups(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return backends, nil
+}
@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+const maxTargetsResponseSize = 4 << 20
+
+type HTTPProvider struct {
+	cfg    config.HTTPDiscoveryConfig
+	client *http.Client
+	etag   string
+}
+
+func NewHTTPProvider(cfg config.HTTPDiscoveryConfig) *HTTPProvider {
+	return &HTTPProvider{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+func (p *HTTPProvider) Name() string {
+	return "http:" + p.cfg.URL
+}
+
+func (p *HTTPProvider) Run(ctx context.Context, update UpdateFunc) {
+	log.Info().
+		Str("source", p.Name()).
+		Dur("interval", p.cfg.Interval).
+		Msg("Starting HTTP discovery")
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.refresh(ctx, update)
+
+		select {
+		case <-ctx.Done():
+			log.Info().Str("source", p.Name()).Msg("Stopping HTTP discovery")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *HTTPProvider) refresh(ctx context.Context, update UpdateFunc) {
+	backends, changed, err := p.Fetch(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("source", p.Name()).Msg("HTTP discovery failed, keeping previous backends")
+		return
+	}
+
+	if changed {
+		update(backends)
+	}
+}
+
+// Fetch запрашивает список бэкендов. Если сервер ответил 304 на
+// If-None-Match с прошлым ETag, changed равен false.
+func (p *HTTPProvider) Fetch(ctx context.Context) (backends []config.BackendConfig, changed bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "LoadBalancer-Discovery/1.0")
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTargetsResponseSize))
+	if err != nil {
+		return nil, false, err
+	}
+
+	backends, err = parseTargetGroups(data, "json")
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.etag = resp.Header.Get("ETag")
+
+	return backends, true, nil
+}
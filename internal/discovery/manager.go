@@ -0,0 +1,171 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/admin"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Manager собирает итоговый набор бэкендов из статической конфигурации,
+// изменений через API и источников обнаружения, и приводит к нему балансировщик.
+type Manager struct {
+	balancer *balancer.DynamicBalancer
+	state    *admin.StateStore
+	mu       sync.Mutex
+	static   []config.BackendConfig
+	sources  map[string][]config.BackendConfig
+}
+
+func NewManager(lb *balancer.DynamicBalancer, state *admin.StateStore, static []config.BackendConfig) *Manager {
+	return &Manager{
+		balancer: lb,
+		state:    state,
+		static:   static,
+		sources:  make(map[string][]config.BackendConfig),
+	}
+}
+
+func (m *Manager) SetStatic(backends []config.BackendConfig) (added, removed []*balancer.Backend) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.static = backends
+	return m.reconcile()
+}
+
+func (m *Manager) Update(source string, backends []config.BackendConfig) (added, removed []*balancer.Backend) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sources[source] = backends
+	added, removed = m.reconcile()
+
+	if len(added) > 0 || len(removed) > 0 {
+		log.Info().
+			Str("source", source).
+			Int("backends", len(backends)).
+			Int("added", len(added)).
+			Int("removed", len(removed)).
+			Msg("Discovered backends changed")
+	}
+
+	return added, removed
+}
+
+// SwapBalancer заменяет алгоритм балансировки. Новый балансировщик строится
+// из текущих бэкендов под той же блокировкой, что и обновления источников,
+// поэтому изменение набора между сборкой и заменой не теряется.
+func (m *Manager) SwapBalancer(algorithm string, opts ...balancer.Option) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backends := m.balancer.Current().GetAllBackends()
+	m.balancer.Swap(balancer.NewBalancer(algorithm, backends, opts...))
+	m.reconcile()
+}
+
+// AddBackend добавляет бэкенд через API: изменение сначала сохраняется в
+// StateStore, затем набор приводится к нему под блокировкой, чтобы
+// одновременная синхронизация с источниками его не удалила.
+func (m *Manager) AddBackend(spec admin.BackendSpec) (*balancer.Backend, error) {
+	u, err := url.Parse(spec.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend URL: %w", err)
+	}
+	spec.URL = u.String()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if balancer.FindBackend(m.balancer, spec.URL) != nil {
+		return nil, admin.ErrBackendExists
+	}
+
+	if err := m.state.RecordAdd(spec); err != nil {
+		return nil, err
+	}
+	m.reconcile()
+
+	backend := balancer.FindBackend(m.balancer, spec.URL)
+	if backend == nil {
+		return nil, fmt.Errorf("backend %s was not registered", spec.URL)
+	}
+
+	return backend, nil
+}
+
+func (m *Manager) RemoveBackend(backendURL string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backend := balancer.FindBackend(m.balancer, backendURL)
+	if backend == nil {
+		return admin.ErrBackendNotFound
+	}
+
+	if err := m.state.RecordRemove(backend.URL.String()); err != nil {
+		return err
+	}
+	m.reconcile()
+
+	return nil
+}
+
+func (m *Manager) SetBackendEnabled(backendURL string, enabled bool) (*balancer.Backend, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backend := balancer.FindBackend(m.balancer, backendURL)
+	if backend == nil {
+		return nil, admin.ErrBackendNotFound
+	}
+
+	if err := m.state.RecordEnabled(backend.URL.String(), enabled); err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		backend.Enable()
+	} else {
+		backend.Disable()
+	}
+
+	return backend, nil
+}
+
+func (m *Manager) Start(ctx context.Context, provider Provider) {
+	go provider.Run(ctx, func(backends []config.BackendConfig) {
+		m.Update(provider.Name(), backends)
+	})
+}
+
+func (m *Manager) Sync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reconcile()
+}
+
+func (m *Manager) reconcile() (added, removed []*balancer.Backend) {
+	desired := make([]config.BackendConfig, 0, len(m.static))
+	desired = append(desired, m.static...)
+	for _, backends := range m.sources {
+		desired = append(desired, backends...)
+	}
+
+	added, removed = balancer.Reconcile(m.balancer, m.state.Apply(desired))
+	for _, backend := range m.balancer.GetAllBackends() {
+		if m.state.IsDisabled(backend.URL.String()) {
+			backend.Disable()
+		}
+	}
+
+	return added, removed
+}
@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/admin"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+)
+
+func TestManager_AddBackend(t *testing.T) {
+	manager, lb := newTestManager(t, []config.BackendConfig{{URL: "http://static"}})
+
+	backend, err := manager.AddBackend(admin.BackendSpec{URL: "http://added", Weight: 3})
+	if err != nil {
+		t.Fatalf("AddBackend() error = %v", err)
+	}
+	if backend.GetWeight() != 3 {
+		t.Errorf("Expected weight 3, got %d", backend.GetWeight())
+	}
+
+	if _, err := manager.AddBackend(admin.BackendSpec{URL: "http://added"}); !errors.Is(err, admin.ErrBackendExists) {
+		t.Errorf("Expected ErrBackendExists, got %v", err)
+	}
+
+	// Синхронизация с источником не должна удалять бэкенд, добавленный через API.
+	manager.Update("dns", []config.BackendConfig{{URL: "http://discovered"}})
+
+	backends := backendURLs(lb)
+	if backends["http://added"] != backend {
+		t.Error("Expected API backend to survive source update")
+	}
+	if backends["http://static"] == nil || backends["http://discovered"] == nil {
+		t.Errorf("Expected static and discovered backends, got %v", backends)
+	}
+}
+
+func TestManager_AddBackendConcurrent(t *testing.T) {
+	manager, lb := newTestManager(t, []config.BackendConfig{{URL: "http://static"}})
+
+	var wg sync.WaitGroup
+	var created, conflicts int
+	var mu sync.Mutex
+
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := manager.AddBackend(admin.BackendSpec{URL: "http://added"})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, admin.ErrBackendExists):
+				conflicts++
+			default:
+				t.Errorf("AddBackend() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			manager.Update("dns", []config.BackendConfig{{URL: "http://discovered"}})
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 || conflicts != 9 {
+		t.Errorf("Expected 1 created and 9 conflicts, got %d and %d", created, conflicts)
+	}
+	if backendURLs(lb)["http://added"] == nil {
+		t.Error("Expected API backend to be registered")
+	}
+}
+
+func TestManager_RemoveBackend(t *testing.T) {
+	manager, lb := newTestManager(t, []config.BackendConfig{{URL: "http://static"}, {URL: "http://other"}})
+
+	if err := manager.RemoveBackend("http://unknown"); !errors.Is(err, admin.ErrBackendNotFound) {
+		t.Errorf("Expected ErrBackendNotFound, got %v", err)
+	}
+
+	if err := manager.RemoveBackend("http://static"); err != nil {
+		t.Fatalf("RemoveBackend() error = %v", err)
+	}
+
+	manager.Sync()
+	if backendURLs(lb)["http://static"] != nil {
+		t.Error("Expected removed static backend to stay removed after sync")
+	}
+}
+
+func TestManager_SetBackendEnabled(t *testing.T) {
+	manager, lb := newTestManager(t, []config.BackendConfig{{URL: "http://static"}})
+
+	if _, err := manager.SetBackendEnabled("http://unknown", false); !errors.Is(err, admin.ErrBackendNotFound) {
+		t.Errorf("Expected ErrBackendNotFound, got %v", err)
+	}
+
+	backend, err := manager.SetBackendEnabled("http://static", false)
+	if err != nil {
+		t.Fatalf("SetBackendEnabled() error = %v", err)
+	}
+	if backend.Enabled() {
+		t.Error("Expected backend to be disabled")
+	}
+
+	manager.Sync()
+	if backendURLs(lb)["http://static"].Enabled() {
+		t.Error("Expected backend to stay disabled after sync")
+	}
+}
+
+func TestManager_PersistErrorKeepsBalancer(t *testing.T) {
+	state, err := admin.NewStateStore(filepath.Join(t.TempDir(), "missing", "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+
+	lb := balancer.NewDynamicBalancer(balancer.NewRoundRobinBalancer(nil))
+	manager := NewManager(lb, state, []config.BackendConfig{{URL: "http://static"}})
+	manager.Sync()
+
+	if _, err := manager.AddBackend(admin.BackendSpec{URL: "http://added"}); err == nil {
+		t.Error("Expected AddBackend() to fail when state is not persisted")
+	}
+	if err := manager.RemoveBackend("http://static"); err == nil {
+		t.Error("Expected RemoveBackend() to fail when state is not persisted")
+	}
+
+	backends := backendURLs(lb)
+	if len(backends) != 1 || backends["http://static"] == nil {
+		t.Errorf("Expected balancer to be unchanged, got %v", backends)
+	}
+}
@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"context"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+type UpdateFunc func(backends []config.BackendConfig)
+
+// Provider периодически или по событиям получает актуальный набор бэкендов
+// из внешнего источника и передаёт его в update. При ошибке получения
+// provider не вызывает update, и предыдущий набор остаётся в силе.
+type Provider interface {
+	Name() string
+
+	Run(ctx context.Context, update UpdateFunc)
+}
+
+func NewProviders(cfg config.DiscoveryConfig) []Provider {
+	var providers []Provider
+
+	for _, dnsCfg := range cfg.DNS {
+		providers = append(providers, NewDNSProvider(dnsCfg, NewResolver(dnsCfg.Nameserver)))
+	}
+
+	for _, fileCfg := range cfg.File {
+		providers = append(providers, NewFileProvider(fileCfg))
+	}
+
+	for _, httpCfg := range cfg.HTTP {
+		providers = append(providers, NewHTTPProvider(httpCfg))
+	}
+
+	return providers
+}
@@ -0,0 +1,197 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func TestFileProvider_Load(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "JSON targets",
+			file:    "targets.json",
+			content: `[{"targets": ["10.0.0.1:8080", "https://10.0.0.2"], "labels": {"zone": "a"}, "weight": 2}]`,
+			want:    []string{"http://10.0.0.1:8080", "https://10.0.0.2"},
+		},
+		{
+			name: "YAML targets with scheme label",
+			file: "targets.yaml",
+			content: `
+- targets: ["10.0.0.3:8443"]
+  labels:
+    __scheme__: https
+`,
+			want: []string{"https://10.0.0.3:8443"},
+		},
+		{
+			name:    "Invalid content",
+			file:    "broken.json",
+			content: `{"targets": `,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write targets file: %v", err)
+			}
+
+			backends, err := NewFileProvider(config.FileDiscoveryConfig{Path: path}).Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(backends) != len(tt.want) {
+				t.Fatalf("Load() got %d backends, want %d", len(backends), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if backends[i].URL != want {
+					t.Errorf("Load() backend[%d] = %s, want %s", i, backends[i].URL, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileProvider_RunReconcilesOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.json")
+	if err := os.WriteFile(path, []byte(`[{"targets": ["10.0.0.1:80", "10.0.0.2:80"]}]`), 0644); err != nil {
+		t.Fatalf("Failed to write targets file: %v", err)
+	}
+
+	manager, lb := newTestManager(t, nil)
+	provider := NewFileProvider(config.FileDiscoveryConfig{Path: path, Interval: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Start(ctx, provider)
+
+	waitFor(t, func() bool { return len(lb.GetAllBackends()) == 2 })
+
+	kept := backendURLs(lb)["http://10.0.0.1:80"]
+	kept.TotalRequests.Store(5)
+
+	if err := os.WriteFile(path, []byte(`[{"targets": ["10.0.0.1:80", "10.0.0.3:80"]}]`), 0644); err != nil {
+		t.Fatalf("Failed to write targets file: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		_, ok := backendURLs(lb)["http://10.0.0.3:80"]
+		return ok
+	})
+
+	backends := backendURLs(lb)
+	if len(backends) != 2 {
+		t.Errorf("after change backends = %v, want 10.0.0.1 and 10.0.0.3", backends)
+	}
+	if backends["http://10.0.0.1:80"] != kept || kept.TotalRequests.Load() != 5 {
+		t.Errorf("unchanged backend should keep its statistics")
+	}
+}
+
+func TestHTTPProvider_FetchWithETag(t *testing.T) {
+	var requests atomic.Int32
+	var notModified atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"targets": ["10.0.0.1:80"], "labels": {"zone": "b"}, "weight": 3}]`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(config.HTTPDiscoveryConfig{
+		URL:      server.URL,
+		Interval: time.Minute,
+		Timeout:  time.Second,
+	})
+
+	backends, changed, err := provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !changed || len(backends) != 1 {
+		t.Fatalf("Fetch() changed = %v, backends = %v", changed, backends)
+	}
+	if backends[0].URL != "http://10.0.0.1:80" || backends[0].Weight != 3 || backends[0].Metadata["zone"] != "b" {
+		t.Errorf("Fetch() backend = %+v", backends[0])
+	}
+
+	_, changed, err = provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("second Fetch() error = %v", err)
+	}
+	if changed {
+		t.Errorf("second Fetch() should report no changes on 304")
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("second Fetch() should send If-None-Match")
+	}
+}
+
+func TestHTTPProvider_ErrorKeepsBackends(t *testing.T) {
+	var fail atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[{"targets": ["10.0.0.1:80"]}]`))
+	}))
+	defer server.Close()
+
+	manager, lb := newTestManager(t, nil)
+	provider := NewHTTPProvider(config.HTTPDiscoveryConfig{
+		URL:      server.URL,
+		Interval: time.Minute,
+		Timeout:  time.Second,
+	})
+	update := func(backends []config.BackendConfig) {
+		manager.Update(provider.Name(), backends)
+	}
+
+	provider.refresh(context.Background(), update)
+	fail.Store(true)
+	provider.refresh(context.Background(), update)
+
+	if len(lb.GetAllBackends()) != 1 {
+		t.Errorf("failed refresh should keep previous backends")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,250 @@
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type HostRecord struct {
+	IP  net.IP
+	TTL time.Duration
+}
+
+type SRVRecord struct {
+	Target   string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+	TTL      time.Duration
+}
+
+// Resolver возвращает записи вместе с TTL. Нулевой TTL означает, что он
+// неизвестен, и источник обновляется с настроенным интервалом.
+type Resolver interface {
+	LookupHost(ctx context.Context, name string) ([]HostRecord, error)
+
+	LookupSRV(ctx context.Context, name string) ([]SRVRecord, error)
+}
+
+func NewResolver(nameserver string) Resolver {
+	if nameserver == "" {
+		return &NetResolver{resolver: net.DefaultResolver}
+	}
+
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+
+	return &ClientResolver{
+		nameserver: nameserver,
+		timeout:    5 * time.Second,
+	}
+}
+
+// NetResolver использует системный резолвер, который не сообщает TTL.
+type NetResolver struct {
+	resolver *net.Resolver
+}
+
+func (r *NetResolver) LookupHost(ctx context.Context, name string) ([]HostRecord, error) {
+	addrs, err := r.resolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]HostRecord, 0, len(addrs))
+	for _, addr := range addrs {
+		records = append(records, HostRecord{IP: addr.IP})
+	}
+
+	return records, nil
+}
+
+func (r *NetResolver) LookupSRV(ctx context.Context, name string) ([]SRVRecord, error) {
+	_, srvs, err := r.resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]SRVRecord, 0, len(srvs))
+	for _, srv := range srvs {
+		records = append(records, SRVRecord{
+			Target:   srv.Target,
+			Port:     srv.Port,
+			Priority: srv.Priority,
+			Weight:   srv.Weight,
+		})
+	}
+
+	return records, nil
+}
+
+// ClientResolver отправляет запросы напрямую указанному DNS-серверу и
+// поэтому знает TTL каждой записи.
+type ClientResolver struct {
+	nameserver string
+	timeout    time.Duration
+}
+
+func (r *ClientResolver) LookupHost(ctx context.Context, name string) ([]HostRecord, error) {
+	var records []HostRecord
+	var lastErr error
+
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, err := r.query(ctx, name, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, answer := range answers {
+			ttl := time.Duration(answer.Header.TTL) * time.Second
+			switch body := answer.Body.(type) {
+			case *dnsmessage.AResource:
+				records = append(records, HostRecord{IP: net.IP(body.A[:]), TTL: ttl})
+			case *dnsmessage.AAAAResource:
+				records = append(records, HostRecord{IP: net.IP(body.AAAA[:]), TTL: ttl})
+			}
+		}
+	}
+
+	if len(records) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return records, nil
+}
+
+func (r *ClientResolver) LookupSRV(ctx context.Context, name string) ([]SRVRecord, error) {
+	answers, err := r.query(ctx, name, dnsmessage.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []SRVRecord
+	for _, answer := range answers {
+		if body, ok := answer.Body.(*dnsmessage.SRVResource); ok {
+			records = append(records, SRVRecord{
+				Target:   body.Target.String(),
+				Port:     body.Port,
+				Priority: body.Priority,
+				Weight:   body.Weight,
+				TTL:      time.Duration(answer.Header.TTL) * time.Second,
+			})
+		}
+	}
+
+	return records, nil
+}
+
+func (r *ClientResolver) query(ctx context.Context, name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns name %q: %w", name, err)
+	}
+
+	id := uint16(rand.Uint32())
+	query, err := (&dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	resp, err := r.exchange(ctx, "udp", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		return nil, fmt.Errorf("failed to parse dns response: %w", err)
+	}
+
+	if msg.Header.Truncated {
+		if resp, err = r.exchange(ctx, "tcp", query); err != nil {
+			return nil, err
+		}
+		if err := msg.Unpack(resp); err != nil {
+			return nil, fmt.Errorf("failed to parse dns response: %w", err)
+		}
+	}
+
+	if msg.Header.ID != id {
+		return nil, errors.New("dns response id mismatch")
+	}
+
+	switch msg.Header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("dns query for %s failed: %s", name, msg.Header.RCode)
+	}
+
+	return msg.Answers, nil
+}
+
+func (r *ClientResolver) exchange(ctx context.Context, network string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, r.nameserver)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, 4096)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+const schemeLabel = "__scheme__"
+
+// targetGroup повторяет формат file_sd из Prometheus: список адресов с
+// общими метками. Метки становятся метаданными бэкендов.
+type targetGroup struct {
+	Targets  []string          `json:"targets" yaml:"targets"`
+	Labels   map[string]string `json:"labels" yaml:"labels"`
+	Weight   int               `json:"weight" yaml:"weight"`
+	Priority int               `json:"priority" yaml:"priority"`
+	Zone     string            `json:"zone" yaml:"zone"`
+}
+
+func parseTargetGroups(data []byte, format string) ([]config.BackendConfig, error) {
+	var groups []targetGroup
+
+	var err error
+	switch format {
+	case "yaml":
+		err = yaml.Unmarshal(data, &groups)
+	default:
+		err = json.Unmarshal(data, &groups)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode targets: %w", err)
+	}
+
+	var backends []config.BackendConfig
+	for _, group := range groups {
+		scheme := group.Labels[schemeLabel]
+		if scheme == "" {
+			scheme = "http"
+		}
+
+		metadata := make(map[string]string, len(group.Labels))
+		for key, value := range group.Labels {
+			if !strings.HasPrefix(key, "__") {
+				metadata[key] = value
+			}
+		}
+
+		for _, target := range group.Targets {
+			backendURL := target
+			if !strings.Contains(target, "://") {
+				backendURL = scheme + "://" + target
+			}
+
+			u, err := url.Parse(backendURL)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid target %q", target)
+			}
+
+			backends = append(backends, config.BackendConfig{
+				URL:      backendURL,
+				Weight:   group.Weight,
+				Priority: group.Priority,
+				Zone:     group.Zone,
+				Metadata: metadata,
+			})
+		}
+	}
+
+	return backends, nil
+}
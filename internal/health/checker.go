@@ -7,10 +7,11 @@ import (
 
 	"go-cloud-camp-2025-test-assignment/config"
 	"go-cloud-camp-2025-test-assignment/internal/balancer"
-
-	"github.com/rs/zerolog/log"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
 )
 
+var log = logger.For(logger.Health)
+
 type HTTPHealthChecker struct {
 	client    *http.Client
 	path      string
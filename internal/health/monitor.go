@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"sync"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+)
+
+// Monitor запускает периодические проверки и позволяет перезапустить их
+// с новыми параметрами.
+type Monitor struct {
+	ctx      context.Context
+	balancer balancer.Balancer
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+}
+
+func NewMonitor(ctx context.Context, lb balancer.Balancer) *Monitor {
+	return &Monitor{
+		ctx:      ctx,
+		balancer: lb,
+	}
+}
+
+func (m *Monitor) Start(cfg config.HealthCheckConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+
+	if !cfg.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancel = cancel
+
+	go balancer.StartHealthChecks(ctx, m.balancer, &cfg, NewHTTPHealthChecker(&cfg))
+}
+
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+)
+
+func newCountingBackend(t *testing.T, status *atomic.Int32) (*balancer.Backend, *atomic.Int32) {
+	t.Helper()
+
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	backend, err := balancer.NewBackend(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	return backend, &checks
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMonitor_Restart(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	backend, checks := newCountingBackend(t, &status)
+
+	monitor := NewMonitor(context.Background(), balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}))
+	defer monitor.Stop()
+
+	monitor.Start(config.HealthCheckConfig{Enabled: true, Interval: time.Hour, Path: "/health"})
+	time.Sleep(50 * time.Millisecond)
+	if got := checks.Load(); got != 0 {
+		t.Fatalf("Expected no checks with 1h interval, got %d", got)
+	}
+
+	// Перезапуск с новым интервалом заменяет прежние проверки.
+	monitor.Start(config.HealthCheckConfig{Enabled: true, Interval: 20 * time.Millisecond, Path: "/health"})
+	waitUntil(t, "health checks", func() bool { return checks.Load() > 0 })
+
+	// Отключённые проверки больше не запускаются.
+	monitor.Start(config.HealthCheckConfig{Enabled: false, Interval: 20 * time.Millisecond, Path: "/health"})
+	time.Sleep(50 * time.Millisecond)
+
+	stopped := checks.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := checks.Load(); got != stopped {
+		t.Errorf("Expected checks to stop after disabling, got %d more", got-stopped)
+	}
+}
+
+func TestMonitor_Stop(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	backend, checks := newCountingBackend(t, &status)
+
+	monitor := NewMonitor(context.Background(), balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}))
+	monitor.Start(config.HealthCheckConfig{Enabled: true, Interval: 20 * time.Millisecond, Path: "/health"})
+	waitUntil(t, "health checks", func() bool { return checks.Load() > 0 })
+
+	monitor.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	stopped := checks.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := checks.Load(); got != stopped {
+		t.Errorf("Expected checks to stop, got %d more", got-stopped)
+	}
+
+	// Повторная остановка безопасна.
+	monitor.Stop()
+}
+
+func TestHTTPHealthChecker_Threshold(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	backend, _ := newCountingBackend(t, &status)
+
+	checker := NewHTTPHealthChecker(&config.HealthCheckConfig{Interval: time.Second, Path: "/health"})
+
+	// Бэкенд считается здоровым, пока число ошибок не достигнет порога.
+	for i := 1; i < checker.threshold; i++ {
+		if !checker.Check(context.Background(), backend) {
+			t.Fatalf("Check %d = unhealthy, want healthy below threshold", i)
+		}
+	}
+	if checker.Check(context.Background(), backend) {
+		t.Error("Expected backend to be unhealthy after reaching threshold")
+	}
+
+	status.Store(http.StatusOK)
+	if !checker.Check(context.Background(), backend) {
+		t.Error("Expected successful check to report healthy")
+	}
+}
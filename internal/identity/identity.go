@@ -0,0 +1,216 @@
+package identity
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
+)
+
+var log = logger.For(logger.RateLimit)
+
+// Способы определить клиента (rate_limit.identity[].type).
+const (
+	TypeHeader = "header"
+	TypeQuery  = "query"
+	TypeJWT    = "jwt"
+	TypeMTLS   = "mtls"
+	TypeIP     = "ip"
+)
+
+// Identity - клиент запроса: идентификатор, по которому считаются лимиты, и
+// способ, которым он определён. ID начинается со способа (header:X-API-Key:key1,
+// jwt:sub:alice, ip:192.0.2.1), чтобы ключ API не совпал с IP или
+// идентификатором из другого источника и не занял чужие лимиты.
+type Identity struct {
+	ID     string
+	Source string
+}
+
+func newIdentity(source, value string) Identity {
+	return Identity{ID: source + ":" + value, Source: source}
+}
+
+// Resolver определяет клиента цепочкой способов: первый вернувший
+// непустой идентификатор побеждает, если не сработал ни один - клиентом
+// считается IP.
+type Resolver struct {
+	extractors []extractor
+	// trustedProxies - адреса прокси, которым разрешено передавать адрес
+	// клиента в X-Forwarded-For (rate_limit.trusted_proxies).
+	trustedProxies []netip.Prefix
+}
+
+type extractor struct {
+	source  string
+	extract func(r *http.Request) string
+}
+
+func NewResolver(cfgs []config.IdentityConfig, trustedProxies []string) (*Resolver, error) {
+	resolver := &Resolver{}
+
+	for _, proxy := range trustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		resolver.trustedProxies = append(resolver.trustedProxies, prefix.Masked())
+	}
+
+	for _, cfg := range cfgs {
+		ext, err := newExtractor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		resolver.extractors = append(resolver.extractors, ext)
+	}
+
+	return resolver, nil
+}
+
+// IP возвращает адрес клиента, определённого по IP.
+func IP(id string) (string, bool) {
+	return strings.CutPrefix(id, TypeIP+":")
+}
+
+func newExtractor(cfg config.IdentityConfig) (extractor, error) {
+	switch cfg.Type {
+	case TypeHeader:
+		return extractor{
+			source: TypeHeader + ":" + cfg.Name,
+			extract: func(r *http.Request) string {
+				return strings.TrimSpace(r.Header.Get(cfg.Name))
+			},
+		}, nil
+	case TypeQuery:
+		return extractor{
+			source: TypeQuery + ":" + cfg.Name,
+			extract: func(r *http.Request) string {
+				return r.URL.Query().Get(cfg.Name)
+			},
+		}, nil
+	case TypeJWT:
+		verifier, err := newJWTVerifier(cfg)
+		if err != nil {
+			return extractor{}, err
+		}
+		return extractor{
+			source:  TypeJWT + ":" + verifier.claim,
+			extract: verifier.extract,
+		}, nil
+	case TypeMTLS:
+		return extractor{
+			source: TypeMTLS,
+			extract: func(r *http.Request) string {
+				return mtlsSubject(r, cfg.Name)
+			},
+		}, nil
+	case TypeIP:
+		return extractor{source: TypeIP, extract: ClientIP}, nil
+	default:
+		return extractor{}, fmt.Errorf("unknown identity type: %s", cfg.Type)
+	}
+}
+
+// Resolve определяет клиента запроса. Нулевой Resolver определяет клиента
+// по IP.
+func (res *Resolver) Resolve(r *http.Request) Identity {
+	if res != nil {
+		for _, ext := range res.extractors {
+			if id := ext.extract(r); id != "" {
+				return newIdentity(ext.source, id)
+			}
+		}
+	}
+
+	return newIdentity(TypeIP, ClientIP(r))
+}
+
+// mtlsSubject возвращает субъект проверенного клиентского сертификата: CN
+// или, если его нет, полное имя. За TLS-терминатором субъект берётся из
+// заголовка header, который тот выставляет.
+func mtlsSubject(r *http.Request, header string) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		subject := r.TLS.VerifiedChains[0][0].Subject
+		if subject.CommonName != "" {
+			return subject.CommonName
+		}
+		return subject.String()
+	}
+
+	if header != "" {
+		return strings.TrimSpace(r.Header.Get(header))
+	}
+
+	return ""
+}
+
+// ClientIP возвращает IP клиента: первый адрес X-Forwarded-For, X-Real-IP или
+// адрес соединения.
+func ClientIP(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		ip, _, _ := strings.Cut(forwardedFor, ",")
+		return strings.TrimSpace(ip)
+	}
+
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+
+	return remoteAddr(r)
+}
+
+// RemoteIP возвращает IP клиента, по которому проверяются диапазоны
+// rate_limit.cidrs. В отличие от ClientIP заголовку X-Forwarded-For он верит,
+// только если соединение пришло от доверенного прокси: адреса перебираются
+// справа налево, и клиентом считается первый недоверенный. Нулевой Resolver
+// возвращает адрес соединения.
+func (res *Resolver) RemoteIP(r *http.Request) string {
+	ip := remoteAddr(r)
+	if res == nil || !res.trusted(ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !res.trusted(hop) {
+			break
+		}
+	}
+
+	return ip
+}
+
+func (res *Resolver) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range res.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remoteAddr(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
@@ -0,0 +1,197 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	jwks := map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa1",
+				"n":   b64(rsaKey.N.Bytes()),
+				"e":   b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec1",
+				"crv": "P-256",
+				"x":   b64(ecKey.X.FillBytes(make([]byte, 32))),
+				"y":   b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	}
+
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("Failed to encode JWKS: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	return path
+}
+
+func signToken(t *testing.T, alg, kid string, claims map[string]any, key crypto.Signer) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + b64(signature)
+}
+
+func TestResolver_Chain(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwks := writeJWKS(t, rsaKey, ecKey)
+
+	resolver, err := NewResolver([]config.IdentityConfig{
+		{Type: TypeHeader, Name: "X-API-Key"},
+		{Type: TypeJWT, Claim: "tenant", JWKSFile: jwks},
+		{Type: TypeQuery, Name: "api_key"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	rsaToken := signToken(t, "RS256", "rsa1", map[string]any{"tenant": "acme", "exp": exp}, rsaKey)
+	ecToken := signToken(t, "ES256", "ec1", map[string]any{"tenant": "globex", "exp": exp}, ecKey)
+	expired := signToken(t, "RS256", "rsa1", map[string]any{"tenant": "acme", "exp": time.Now().Add(-time.Minute).Unix()}, rsaKey)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	forged := signToken(t, "RS256", "rsa1", map[string]any{"tenant": "acme", "exp": exp}, otherKey)
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    Identity
+	}{
+		{"api key wins", "/", map[string]string{"X-API-Key": "key1", "Authorization": "Bearer " + rsaToken}, Identity{"header:X-API-Key:key1", "header:X-API-Key"}},
+		{"rsa jwt", "/", map[string]string{"Authorization": "Bearer " + rsaToken}, Identity{"jwt:tenant:acme", "jwt:tenant"}},
+		{"ec jwt", "/", map[string]string{"Authorization": "bearer " + ecToken}, Identity{"jwt:tenant:globex", "jwt:tenant"}},
+		{"expired jwt falls back", "/?api_key=key2", map[string]string{"Authorization": "Bearer " + expired}, Identity{"query:api_key:key2", "query:api_key"}},
+		{"forged jwt falls back to ip", "/", map[string]string{"Authorization": "Bearer " + forged}, Identity{"ip:192.0.2.1", "ip"}},
+		{"forwarded ip", "/", map[string]string{"X-Forwarded-For": "203.0.113.5, 10.0.0.1"}, Identity{"ip:203.0.113.5", "ip"}},
+		{"key equal to an ip", "/", map[string]string{"X-API-Key": "192.0.2.1"}, Identity{"header:X-API-Key:192.0.2.1", "header:X-API-Key"}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.target, nil)
+		for name, value := range tt.headers {
+			r.Header.Set(name, value)
+		}
+
+		if got := resolver.Resolve(r); got != tt.want {
+			t.Errorf("%s: Resolve() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_Nil(t *testing.T) {
+	var resolver *Resolver
+
+	r := httptest.NewRequest("GET", "/", nil)
+	if got := resolver.Resolve(r); got.ID != "ip:192.0.2.1" || got.Source != TypeIP {
+		t.Errorf("Resolve() = %+v, want client IP", got)
+	}
+}
+
+func TestResolver_RemoteIP(t *testing.T) {
+	resolver, err := NewResolver(nil, []string{"192.0.2.0/24", "10.0.0.1/32"})
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"untrusted peer ignores forwarded", "203.0.113.7:1234", []string{"10.1.2.3"}, "203.0.113.7"},
+		{"trusted peer", "192.0.2.1:1234", []string{"203.0.113.5"}, "203.0.113.5"},
+		{"spoofed first hop", "192.0.2.1:1234", []string{"10.1.2.3, 203.0.113.5"}, "203.0.113.5"},
+		{"trusted hops skipped", "192.0.2.1:1234", []string{"203.0.113.5, 10.0.0.1", "192.0.2.9"}, "203.0.113.5"},
+		{"only trusted hops", "192.0.2.1:1234", []string{"10.0.0.1"}, "10.0.0.1"},
+		{"trusted peer without header", "192.0.2.1:1234", nil, "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, value := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+
+		if got := resolver.RemoteIP(r); got != tt.want {
+			t.Errorf("%s: RemoteIP() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	var nilResolver *Resolver
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "10.1.2.3")
+	if got := nilResolver.RemoteIP(r); got != "192.0.2.1" {
+		t.Errorf("nil RemoteIP() = %q, want connection address", got)
+	}
+
+	if _, err := NewResolver(nil, []string{"not-a-cidr"}); err == nil {
+		t.Error("Expected error for invalid trusted proxy")
+	}
+}
+
+func TestNewResolver_InvalidJWKS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(`{"keys":[]}`), 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	if _, err := NewResolver([]config.IdentityConfig{{Type: TypeJWT, JWKSFile: path}}, nil); err == nil {
+		t.Error("Expected error for JWKS without keys")
+	}
+}
@@ -0,0 +1,332 @@
+package identity
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+var (
+	errMalformedToken = errors.New("malformed token")
+	errUnknownKey     = errors.New("no matching key")
+	errBadSignature   = errors.New("invalid signature")
+	errExpired        = errors.New("token expired or not yet valid")
+)
+
+// jwtVerifier извлекает утверждение claim из JWT, подпись которого проверена
+// ключами из локального JWKS-файла. Поддерживаются алгоритмы RS*, PS* и ES*.
+type jwtVerifier struct {
+	header string
+	claim  string
+	keys   []jwk
+	now    func() time.Time
+}
+
+type jwk struct {
+	id  string
+	key crypto.PublicKey
+}
+
+func newJWTVerifier(cfg config.IdentityConfig) (*jwtVerifier, error) {
+	keys, err := loadJWKS(cfg.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &jwtVerifier{
+		header: cfg.Name,
+		claim:  cfg.Claim,
+		keys:   keys,
+		now:    time.Now,
+	}
+	if v.header == "" {
+		v.header = "Authorization"
+	}
+	if v.claim == "" {
+		v.claim = "sub"
+	}
+
+	return v, nil
+}
+
+func (v *jwtVerifier) extract(r *http.Request) string {
+	token := strings.TrimSpace(r.Header.Get(v.header))
+	if token == "" {
+		return ""
+	}
+	if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+
+	id, err := v.verify(token)
+	if err != nil {
+		log.Ctx(r.Context()).Debug().Err(err).Msg("JWT rejected for client identification")
+		return ""
+	}
+
+	return id
+}
+
+// verify проверяет подпись и сроки токена и возвращает значение утверждения.
+func (v *jwtVerifier) verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errMalformedToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if !v.verifySignature(header.Alg, header.Kid, signed, signature) {
+		return "", errBadSignature
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", err
+	}
+
+	now := v.now().Unix()
+	if exp, ok := numericClaim(claims, "exp"); ok && now >= exp {
+		return "", errExpired
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now < nbf {
+		return "", errExpired
+	}
+
+	switch value := claims[v.claim].(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		return "", fmt.Errorf("claim %q is missing or not a string", v.claim)
+	}
+}
+
+func (v *jwtVerifier) verifySignature(alg, kid string, signed, signature []byte) bool {
+	hash, ok := algHash(alg)
+	if !ok {
+		return false
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	for _, k := range v.keys {
+		if kid != "" && k.id != kid {
+			continue
+		}
+
+		switch key := k.key.(type) {
+		case *rsa.PublicKey:
+			switch alg[:2] {
+			case "RS":
+				if rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+					return true
+				}
+			case "PS":
+				if rsa.VerifyPSS(key, hash, digest, signature, nil) == nil {
+					return true
+				}
+			}
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if alg[:2] != "ES" || len(signature) != 2*size {
+				continue
+			}
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func algHash(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, false
+	}
+
+	switch alg[:2] {
+	case "RS", "PS", "ES":
+	default:
+		return 0, false
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errMalformedToken
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return errMalformedToken
+	}
+
+	return nil
+}
+
+func numericClaim(claims map[string]any, name string) (int64, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	value, err := number.Float64()
+	if err != nil {
+		return 0, false
+	}
+
+	return int64(value), true
+}
+
+// loadJWKS читает открытые ключи RSA и EC из JWKS-файла. Ключи для
+// шифрования (use: enc) пропускаются.
+func loadJWKS(path string) ([]jwk, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	var keys []jwk
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k.N, k.E)
+		case "EC":
+			key, err = ecKey(k.Crv, k.X, k.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+
+		keys = append(keys, jwk{id: k.Kid, key: key})
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s contains no signing keys", path)
+	}
+
+	return keys, nil
+}
+
+func rsaKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}
+	if key.N.Sign() == 0 || key.E == 0 {
+		return nil, errors.New("empty modulus or exponent")
+	}
+
+	return key, nil
+}
+
+func ecKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", crv)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("point is not on curve")
+	}
+
+	return key, nil
+}
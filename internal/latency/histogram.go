@@ -0,0 +1,145 @@
+package latency
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Гистограмма хранит значения в микросекундах в лог-линейных бакетах, как
+// HDR Histogram: каждая степень двойки делится на subBuckets частей, поэтому
+// относительная погрешность не превышает 1/subBuckets (~6%).
+const (
+	subBucketBits = 4
+	subBuckets    = 1 << subBucketBits
+	maxValueBits  = 34 // ~4.7 часа в микросекундах
+	bucketCount   = (maxValueBits-subBucketBits)*subBuckets + subBuckets
+)
+
+// Histogram - гистограмма без блокировок: запись - несколько атомарных
+// операций, чтение может идти параллельно с записью.
+type Histogram struct {
+	counts [bucketCount]atomic.Int64
+	count  atomic.Int64
+	max    atomic.Int64
+}
+
+func (h *Histogram) Record(d time.Duration) {
+	v := max(d.Microseconds(), 0)
+
+	h.counts[bucketIndex(v)].Add(1)
+	h.count.Add(1)
+
+	for {
+		current := h.max.Load()
+		if v <= current || h.max.CompareAndSwap(current, v) {
+			break
+		}
+	}
+}
+
+func (h *Histogram) reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.count.Store(0)
+	h.max.Store(0)
+}
+
+// mergeInto добавляет значения гистограммы к снимку.
+func (h *Histogram) mergeInto(s *snapshot) {
+	for i := range h.counts {
+		s.counts[i] += h.counts[i].Load()
+	}
+	s.count += h.count.Load()
+	s.max = max(s.max, h.max.Load())
+}
+
+func (h *Histogram) Percentiles() Percentiles {
+	var s snapshot
+	h.mergeInto(&s)
+	return s.percentiles()
+}
+
+func bucketIndex(v int64) int {
+	if v < 2*subBuckets {
+		return int(v)
+	}
+
+	exp := bits.Len64(uint64(v)) - subBucketBits - 1
+	if exp > maxValueBits-subBucketBits-1 {
+		return bucketCount - 1
+	}
+
+	return (exp+1)*subBuckets + int(v>>exp) - subBuckets
+}
+
+// bucketUpperBound возвращает наибольшее значение, попадающее в бакет.
+func bucketUpperBound(i int) int64 {
+	if i < 2*subBuckets {
+		return int64(i)
+	}
+
+	exp := i/subBuckets - 1
+	mantissa := int64(i%subBuckets + subBuckets)
+
+	return (mantissa+1)<<exp - 1
+}
+
+type snapshot struct {
+	counts [bucketCount]int64
+	count  int64
+	max    int64
+}
+
+// Percentiles - перцентили задержки в миллисекундах.
+type Percentiles struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+func (s *snapshot) percentiles() Percentiles {
+	if s.count == 0 {
+		return Percentiles{}
+	}
+
+	return Percentiles{
+		Count: s.count,
+		P50:   toMillis(s.quantile(0.50)),
+		P90:   toMillis(s.quantile(0.90)),
+		P99:   toMillis(s.quantile(0.99)),
+		Max:   toMillis(s.max),
+	}
+}
+
+func (s *snapshot) quantile(q float64) int64 {
+	// Счётчик бакетов и общий счётчик обновляются не атомарно вместе,
+	// поэтому ранг считается по сумме бакетов.
+	var total int64
+	for _, c := range s.counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(q*float64(total) + 0.5)
+	rank = min(max(rank, 1), total)
+
+	var seen int64
+	for i, c := range s.counts {
+		seen += c
+		if seen >= rank {
+			return min(bucketUpperBound(i), s.max)
+		}
+	}
+
+	return s.max
+}
+
+func toMillis(us int64) float64 {
+	return float64(us) / 1000
+}
@@ -0,0 +1,104 @@
+package latency
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucketIndex(t *testing.T) {
+	for _, v := range []int64{0, 1, 31, 32, 33, 63, 64, 1000, 123456, 1 << 33} {
+		i := bucketIndex(v)
+		if upper := bucketUpperBound(i); v > upper {
+			t.Errorf("value %d above upper bound %d of its bucket %d", v, upper, i)
+		}
+		if i > 0 && v <= bucketUpperBound(i-1) {
+			t.Errorf("value %d fits into previous bucket %d", v, i-1)
+		}
+	}
+
+	if got := bucketIndex(1 << 40); got != bucketCount-1 {
+		t.Errorf("bucketIndex() for huge value = %d, want last bucket", got)
+	}
+}
+
+func TestHistogram_Percentiles(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	p := h.Percentiles()
+	if p.Count != 1000 || p.Max != 1000 {
+		t.Fatalf("Percentiles() count = %d, max = %v", p.Count, p.Max)
+	}
+
+	checks := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"p50", p.P50, 500},
+		{"p90", p.P90, 900},
+		{"p99", p.P99, 990},
+	}
+	for _, c := range checks {
+		if c.got < c.want || c.got > c.want*1.07 {
+			t.Errorf("%s = %v, want %v within bucket precision", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestHistogram_ConcurrentRecord(t *testing.T) {
+	var h Histogram
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				h.Record(time.Duration(i) * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := h.Percentiles(); p.Count != 8000 || p.Max != 0.999 {
+		t.Errorf("Percentiles() = %+v, want 8000 values with max 0.999ms", p)
+	}
+}
+
+func TestWindow_Expiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tracker := newTracker(func() time.Time { return now })
+
+	tracker.RecordTotal(100 * time.Millisecond)
+	now = now.Add(2 * time.Minute)
+	tracker.RecordTotal(10 * time.Millisecond)
+
+	stats := tracker.Stats().Total
+	if stats.Lifetime.Count != 2 || stats.Lifetime.Max != 100 {
+		t.Errorf("lifetime = %+v, want both values", stats.Lifetime)
+	}
+	if stats.Last1m.Count != 1 || stats.Last1m.Max != 10 {
+		t.Errorf("last 1m = %+v, want only the recent value", stats.Last1m)
+	}
+	if stats.Last5m.Count != 2 {
+		t.Errorf("last 5m = %+v, want both values", stats.Last5m)
+	}
+
+	now = now.Add(10 * time.Minute)
+	stats = tracker.Stats().Total
+	if stats.Last5m.Count != 0 || stats.Lifetime.Count != 2 {
+		t.Errorf("after 10m window = %+v, lifetime = %+v", stats.Last5m, stats.Lifetime)
+	}
+
+	// Слот, занятый 10 минут назад, должен очиститься при повторном использовании.
+	tracker.RecordTotal(time.Millisecond)
+	if got := tracker.Stats().Total.Last1m; got.Count != 1 {
+		t.Errorf("reused slot = %+v, want only the new value", got)
+	}
+	if got := tracker.Stats().TTFB.Lifetime; got.Count != 0 {
+		t.Errorf("TTFB should be tracked separately, got %+v", got)
+	}
+}
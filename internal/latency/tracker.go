@@ -0,0 +1,67 @@
+package latency
+
+import (
+	"time"
+)
+
+// Tracker собирает распределения времени до первого байта ответа (TTFB) и
+// полного времени запроса за всё время работы и за скользящие окна.
+type Tracker struct {
+	ttfb  series
+	total series
+}
+
+type series struct {
+	lifetime Histogram
+	window   *Window
+}
+
+func NewTracker() *Tracker {
+	return newTracker(time.Now)
+}
+
+func newTracker(now func() time.Time) *Tracker {
+	return &Tracker{
+		ttfb:  series{window: newWindow(now)},
+		total: series{window: newWindow(now)},
+	}
+}
+
+func (t *Tracker) RecordTTFB(d time.Duration) {
+	t.ttfb.record(d)
+}
+
+func (t *Tracker) RecordTotal(d time.Duration) {
+	t.total.record(d)
+}
+
+func (s *series) record(d time.Duration) {
+	s.lifetime.Record(d)
+	s.window.Record(d)
+}
+
+type Stats struct {
+	TTFB  WindowStats `json:"ttfb"`
+	Total WindowStats `json:"total"`
+}
+
+type WindowStats struct {
+	Lifetime Percentiles `json:"lifetime"`
+	Last1m   Percentiles `json:"last_1m"`
+	Last5m   Percentiles `json:"last_5m"`
+}
+
+func (t *Tracker) Stats() Stats {
+	return Stats{
+		TTFB:  t.ttfb.stats(),
+		Total: t.total.stats(),
+	}
+}
+
+func (s *series) stats() WindowStats {
+	return WindowStats{
+		Lifetime: s.lifetime.Percentiles(),
+		Last1m:   s.window.Percentiles(time.Minute),
+		Last5m:   s.window.Percentiles(5 * time.Minute),
+	}
+}
@@ -0,0 +1,66 @@
+package latency
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	slotDuration = 10 * time.Second
+	slotCount    = 30 // 5 минут
+)
+
+// Window хранит значения за последние slotCount*slotDuration в кольце
+// гистограмм. Слот, время которого прошло, очищается первым записавшим в
+// него. Значения, записанные одновременно с очисткой, могут потеряться, что
+// допустимо для статистики.
+type Window struct {
+	slots [slotCount]windowSlot
+	now   func() time.Time
+}
+
+type windowSlot struct {
+	epoch     atomic.Int64
+	histogram Histogram
+}
+
+func newWindow(now func() time.Time) *Window {
+	w := &Window{now: now}
+	for i := range w.slots {
+		w.slots[i].epoch.Store(-1)
+	}
+	return w
+}
+
+func (w *Window) Record(d time.Duration) {
+	epoch := w.epoch()
+	slot := &w.slots[epoch%slotCount]
+
+	if current := slot.epoch.Load(); current != epoch {
+		if slot.epoch.CompareAndSwap(current, epoch) {
+			slot.histogram.reset()
+		}
+	}
+
+	slot.histogram.Record(d)
+}
+
+// Percentiles считает перцентили за последний период, не больше длины окна.
+func (w *Window) Percentiles(period time.Duration) Percentiles {
+	epoch := w.epoch()
+	slots := min(int64(period/slotDuration), slotCount)
+
+	var s snapshot
+	for i := range w.slots {
+		slotEpoch := w.slots[i].epoch.Load()
+		if slotEpoch > epoch-slots && slotEpoch <= epoch {
+			w.slots[i].histogram.mergeInto(&s)
+		}
+	}
+
+	return s.percentiles()
+}
+
+func (w *Window) epoch() int64 {
+	return w.now().UnixNano() / int64(slotDuration)
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default - реестр, метрики которого отдаются на /metrics.
+var Default = NewRegistry()
+
+var (
+	Requests = Default.NewCounterVec(
+		"lb_requests_total",
+		"Total number of HTTP requests by route, backend and status code class.",
+		"route", "backend", "code",
+	)
+
+	RequestDuration = Default.NewHistogramVec(
+		"lb_request_duration_seconds",
+		"HTTP request latency by route and backend.",
+		DefBuckets,
+		"route", "backend",
+	)
+
+	HealthCheckDuration = Default.NewHistogramVec(
+		"lb_health_check_duration_seconds",
+		"Duration of backend health checks by result.",
+		DefBuckets,
+		"backend", "result",
+	)
+
+	RateLimitDecisions = Default.NewCounterVec(
+		"lb_ratelimit_decisions_total",
+		"Rate limit decisions: allowed, denied or error.",
+		"decision",
+	)
+
+	ConcurrencyDecisions = Default.NewCounterVec(
+		"lb_concurrency_decisions_total",
+		"Per-client concurrency limit decisions: acquired, denied or error.",
+		"decision",
+	)
+
+	StorageErrors = Default.NewCounterVec(
+		"lb_storage_redis_errors_total",
+		"Redis storage errors by operation.",
+		"operation",
+	)
+
+	StorageDuration = Default.NewHistogramVec(
+		"lb_storage_redis_duration_seconds",
+		"Redis storage operation latency.",
+		[]float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		"operation",
+	)
+)
+
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// ObserveStorage учитывает длительность операции с Redis и её ошибку.
+func ObserveStorage(operation string, start time.Time, err error) {
+	StorageDuration.Observe(time.Since(start).Seconds(), operation)
+	if err != nil {
+		StorageErrors.Inc(operation)
+	}
+}
+
+type requestInfoKey struct{}
+
+type requestInfo struct {
+	route   string
+	backend string
+}
+
+// SetRoute задаёт маршрут запроса для меток вместо шаблона http.ServeMux.
+// Значения должны браться из конечного набора, например из конфигурации.
+func SetRoute(ctx context.Context, route string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.route = route
+	}
+}
+
+// SetBackend запоминает бэкенд, обработавший запрос, чтобы Instrument
+// добавил его в метки.
+func SetBackend(ctx context.Context, backend string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.backend = backend
+	}
+}
+
+// Instrument считает запросы и их длительность. В качестве маршрута
+// используется значение SetRoute, а если его нет - шаблон http.ServeMux, так
+// что число серий не зависит от путей запросов.
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestInfo{}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		r = r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+		next.ServeHTTP(rec, r)
+
+		route := info.route
+		if route == "" {
+			route = r.Pattern
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+
+		Requests.Inc(route, info.backend, CodeClass(rec.status))
+		RequestDuration.Observe(time.Since(start).Seconds(), route, info.backend)
+	})
+}
+
+// CodeClass возвращает класс кода ответа: "2xx", "5xx" и т.д.
+func CodeClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
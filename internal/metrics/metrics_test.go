@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	registry := NewRegistry()
+
+	counter := registry.NewCounterVec("test_requests_total", "Test counter.", "backend", "code")
+	counter.Inc("http://b", "2xx")
+	counter.Add(2, "http://a", "5xx")
+
+	histogram := registry.NewHistogramVec("test_duration_seconds", "Test histogram.", []float64{0.1, 1}, "backend")
+	histogram.Observe(0.05, "http://a")
+	histogram.Observe(0.1, "http://a")
+	histogram.Observe(5, "http://a")
+
+	registry.NewGaugeFunc("test_up", "Test gauge.", func(emit func(value float64, labelValues ...string)) {
+		emit(1, `quote"d`)
+	}, "backend")
+
+	var sb strings.Builder
+	if _, err := registry.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want := `# HELP test_requests_total Test counter.
+# TYPE test_requests_total counter
+test_requests_total{backend="http://a",code="5xx"} 2
+test_requests_total{backend="http://b",code="2xx"} 1
+# HELP test_duration_seconds Test histogram.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{backend="http://a",le="0.1"} 2
+test_duration_seconds_bucket{backend="http://a",le="1"} 2
+test_duration_seconds_bucket{backend="http://a",le="+Inf"} 3
+test_duration_seconds_sum{backend="http://a"} 5.15
+test_duration_seconds_count{backend="http://a"} 3
+# HELP test_up Test gauge.
+# TYPE test_up gauge
+test_up{backend="quote\"d"} 1
+`
+	if got := sb.String(); got != want {
+		t.Errorf("WriteTo() output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		SetBackend(r.Context(), "http://backend")
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	handler := Instrument(mux)
+
+	before := Requests.Value("/api/", "http://backend", "5xx")
+	notFoundBefore := Requests.Value("unmatched", "", "4xx")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/42", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+
+	if got := Requests.Value("/api/", "http://backend", "5xx"); got != before+1 {
+		t.Errorf("Requests for route /api/ = %d, want %d", got, before+1)
+	}
+	if got := Requests.Value("unmatched", "", "4xx"); got != notFoundBefore+1 {
+		t.Errorf("Requests for unmatched route = %d, want %d", got, notFoundBefore+1)
+	}
+}
+
+func TestInstrument_SetRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/upload" {
+			SetRoute(r.Context(), "POST /upload")
+		}
+		SetBackend(r.Context(), "http://routed")
+	})
+	handler := Instrument(mux)
+
+	routeBefore := Requests.Value("POST /upload", "http://routed", "2xx")
+	rootBefore := Requests.Value("/", "http://routed", "2xx")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+
+	if got := Requests.Value("POST /upload", "http://routed", "2xx"); got != routeBefore+1 {
+		t.Errorf("Requests for route POST /upload = %d, want %d", got, routeBefore+1)
+	}
+	if got := Requests.Value("/", "http://routed", "2xx"); got != rootBefore+1 {
+		t.Errorf("Requests without route = %d, want %d", got, rootBefore+1)
+	}
+}
@@ -0,0 +1,305 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// collector - семейство метрик, которое умеет записать себя в текстовом
+// формате Prometheus.
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.RUnlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type desc struct {
+	name       string
+	help       string
+	labelNames []string
+}
+
+func (d *desc) writeHeader(w *bufio.Writer, typ string) {
+	w.WriteString("# HELP " + d.name + " " + escapeHelp(d.help) + "\n")
+	w.WriteString("# TYPE " + d.name + " " + typ + "\n")
+}
+
+// series хранит значения метрики по наборам значений меток.
+type series[T any] struct {
+	mu     sync.RWMutex
+	values map[string]*T
+	labels map[string][]string
+	init   func() *T
+}
+
+func newSeries[T any](init func() *T) series[T] {
+	return series[T]{
+		values: make(map[string]*T),
+		labels: make(map[string][]string),
+		init:   init,
+	}
+}
+
+func (s *series[T]) get(labelValues []string) *T {
+	key := strings.Join(labelValues, "\xff")
+
+	s.mu.RLock()
+	v, ok := s.values[key]
+	s.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.values[key]; ok {
+		return v
+	}
+	v = s.init()
+	s.values[key] = v
+	s.labels[key] = slices.Clone(labelValues)
+
+	return v
+}
+
+// each обходит значения в порядке меток, чтобы вывод был стабильным.
+func (s *series[T]) each(fn func(labelValues []string, v *T)) {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	s.mu.RUnlock()
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		s.mu.RLock()
+		v, labels := s.values[key], s.labels[key]
+		s.mu.RUnlock()
+		fn(labels, v)
+	}
+}
+
+type CounterVec struct {
+	desc
+	series series[atomic.Uint64]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name: name, help: help, labelNames: labelNames},
+		series: newSeries(func() *atomic.Uint64 { return new(atomic.Uint64) }),
+	}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(n uint64, labelValues ...string) {
+	c.series.get(labelValues).Add(n)
+}
+
+func (c *CounterVec) Value(labelValues ...string) uint64 {
+	return c.series.get(labelValues).Load()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w, "counter")
+	c.series.each(func(labelValues []string, v *atomic.Uint64) {
+		writeSample(w, c.name, c.labelNames, labelValues, "", "", float64(v.Load()))
+	})
+}
+
+type HistogramVec struct {
+	desc
+	buckets []float64
+	series  series[histogram]
+}
+
+type histogram struct {
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Uint64
+}
+
+// DefBuckets - границы бакетов в секундах, подходящие для задержек HTTP.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, labelNames: labelNames},
+		buckets: buckets,
+	}
+	h.series = newSeries(func() *histogram {
+		return &histogram{counts: make([]atomic.Uint64, len(buckets))}
+	})
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	s := h.series.get(labelValues)
+
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		s.counts[i].Add(1)
+	}
+	for {
+		old := s.sum.Load()
+		if s.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+value)) {
+			break
+		}
+	}
+	s.count.Add(1)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+	h.series.each(func(labelValues []string, s *histogram) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i].Load()
+			writeSample(w, h.name+"_bucket", h.labelNames, labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		count := s.count.Load()
+		writeSample(w, h.name+"_bucket", h.labelNames, labelValues, "le", "+Inf", float64(count))
+		writeSample(w, h.name+"_sum", h.labelNames, labelValues, "", "", math.Float64frombits(s.sum.Load()))
+		writeSample(w, h.name+"_count", h.labelNames, labelValues, "", "", float64(count))
+	})
+}
+
+// GaugeFunc вычисляет значения в момент чтения метрик. Подходит для
+// состояния, которое уже хранится в других структурах, например числа
+// активных соединений бэкендов.
+type GaugeFunc struct {
+	desc
+	collect func(emit func(value float64, labelValues ...string))
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, collect func(emit func(value float64, labelValues ...string)), labelNames ...string) *GaugeFunc {
+	g := &GaugeFunc{
+		desc:    desc{name: name, help: help, labelNames: labelNames},
+		collect: collect,
+	}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w, "gauge")
+	g.collect(func(value float64, labelValues ...string) {
+		writeSample(w, g.name, g.labelNames, labelValues, "", "", value)
+	})
+}
+
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+
+	if len(labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			var labelValue string
+			if i < len(labelValues) {
+				labelValue = labelValues[i]
+			}
+			w.WriteString(labelName + `="` + escapeLabel(labelValue) + `"`)
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
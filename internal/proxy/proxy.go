@@ -1,26 +1,41 @@
 package proxy
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httputil"
-	"strings"
+	"strconv"
 	"time"
 
 	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/accesslog"
 	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/identity"
+	"go-cloud-camp-2025-test-assignment/internal/latency"
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
 	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
-
-	"github.com/rs/zerolog/log"
+	"go-cloud-camp-2025-test-assignment/internal/requestid"
+	"go-cloud-camp-2025-test-assignment/internal/tracing"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
 )
 
+var log = logger.For(logger.Proxy)
+
 type Proxy struct {
 	balancer      balancer.Balancer
 	rateLimiter   ratelimit.RateLimiter
+	concurrency   *ratelimit.ConcurrencyLimiter
+	costRules     *ratelimit.CostRules
+	identity      *identity.Resolver
 	errorHandler  ErrorHandler
 	config        *config.Config
 	requestLogger RequestLogger
+	sticky        *stickySessions
+	tracer        *tracing.Tracer
+	latency       *latency.Tracker
+	accessLog     *accesslog.Logger
 }
 
 type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
@@ -33,14 +48,23 @@ func NewProxy(loadBalancer balancer.Balancer, cfg *config.Config, opts ...ProxyO
 	p := &Proxy{
 		balancer: loadBalancer,
 		config:   cfg,
+		tracer:   tracing.NewNoopTracer(),
+		latency:  latency.NewTracker(),
 		errorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
 
-			log.Error().Err(err).Str("path", r.URL.Path).Msg("Proxy error")
+			log.Ctx(r.Context()).Error().Err(err).Str("path", r.URL.Path).Msg("Proxy error")
 			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
 		},
 		requestLogger: func(r *http.Request, backend *balancer.Backend, statusCode int, duration time.Duration, err error) {
 
-			logger := log.With().
+			event := log.Ctx(r.Context()).Info()
+			msg := "Proxy request completed"
+			if err != nil {
+				event = log.Ctx(r.Context()).Warn().Err(err)
+				msg = "Proxy request completed with error"
+			}
+
+			event = event.
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
 				Str("remote_addr", r.RemoteAddr).
@@ -48,19 +72,17 @@ func NewProxy(loadBalancer balancer.Balancer, cfg *config.Config, opts ...ProxyO
 				Dur("duration", duration)
 
 			if backend != nil {
-				logger = logger.Str("backend", backend.URL.String())
+				event = event.Str("backend", backend.URL.String())
 			}
 
-			if err != nil {
-				l := logger.Err(err).Logger()
-				l.Warn().Msg("Proxy request completed with error")
-			} else {
-				l := logger.Err(err).Logger()
-				l.Info().Msg("Proxy request completed")
-			}
+			event.Msg(msg)
 		},
 	}
 
+	if cfg.Sticky.Enabled {
+		p.sticky = newStickySessions(cfg.Sticky)
+	}
+
 	for _, opt := range opts {
 		opt(p)
 	}
@@ -80,61 +102,246 @@ func WithRequestLogger(logger RequestLogger) ProxyOption {
 	}
 }
 
+func WithTracer(tracer *tracing.Tracer) ProxyOption {
+	return func(p *Proxy) {
+		p.tracer = tracer
+	}
+}
+
+func WithAccessLog(accessLog *accesslog.Logger) ProxyOption {
+	return func(p *Proxy) {
+		p.accessLog = accessLog
+	}
+}
+
 func WithRateLimiter(limiter ratelimit.RateLimiter) ProxyOption {
 	return func(p *Proxy) {
 		p.rateLimiter = limiter
 	}
 }
 
+// WithIdentity задаёт способ определить клиента для лимитов. По умолчанию
+// клиент определяется по IP.
+func WithIdentity(resolver *identity.Resolver) ProxyOption {
+	return func(p *Proxy) {
+		p.identity = resolver
+	}
+}
+
+// WithCostRules задаёт стоимость запросов в токенах rate limiter.
+func WithCostRules(rules *ratelimit.CostRules) ProxyOption {
+	return func(p *Proxy) {
+		p.costRules = rules
+	}
+}
+
+func WithConcurrencyLimiter(limiter *ratelimit.ConcurrencyLimiter) ProxyOption {
+	return func(p *Proxy) {
+		p.concurrency = limiter
+	}
+}
+
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	var backend *balancer.Backend
 	var statusCode int = http.StatusOK
 	var responseErr error
 
+	ctx, span := p.tracer.Start(tracing.Extract(r.Context(), r.Header), "HTTP "+r.Method, tracing.KindServer)
+	r = r.WithContext(ctx)
+	span.SetAttributes(
+		tracing.String("http.request.method", r.Method),
+		tracing.String("url.path", r.URL.Path),
+		tracing.String("client.address", identity.ClientIP(r)),
+	)
+
+	if route := ratelimit.MatchRoute(p.config.RateLimit.Routes, r.Method, r.URL.Path); route != "" {
+		metrics.SetRoute(r.Context(), route)
+	}
+
+	var rateLimitDecision string
+	var rateLimitCost int
+	var client identity.Identity
+	var upstreamLatency time.Duration
+	var requestBody *accesslog.Body
+	var responseWriter *accesslog.ResponseWriter
+	if p.accessLog != nil {
+		if r.Body != nil && r.Body != http.NoBody {
+			requestBody = accesslog.NewBody(r.Body)
+			r.Body = requestBody
+		}
+		responseWriter = accesslog.NewResponseWriter(w)
+		w = responseWriter
+	}
+
 	defer func() {
+		span.SetAttributes(tracing.Int("http.response.status_code", statusCode))
+		if statusCode >= 500 {
+			span.SetStatus(tracing.StatusError, http.StatusText(statusCode))
+		}
+		span.End()
+
 		p.requestLogger(r, backend, statusCode, time.Since(start), responseErr)
+
+		if p.accessLog != nil {
+			entry := accesslog.NewEntry(r, start, identity.ClientIP(r), requestid.FromContext(ctx))
+			entry.Status = statusCode
+			entry.Duration = time.Since(start)
+			entry.BytesIn = requestBody.BytesRead()
+			entry.BytesOut = responseWriter.BytesWritten()
+			entry.UpstreamLatency = upstreamLatency
+			entry.RateLimit = rateLimitDecision
+			entry.RateLimitCost = rateLimitCost
+			entry.ClientID = client.ID
+			if backend != nil {
+				entry.Upstream = backend.URL.Host
+			}
+			if responseErr != nil {
+				entry.Error = responseErr.Error()
+			}
+
+			if err := p.accessLog.Log(entry); err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to write access log")
+			}
+		}
 	}()
 
+	if p.rateLimiter != nil || p.concurrency != nil {
+		client = p.identity.Resolve(r)
+	}
+
+	if p.concurrency != nil {
+		release, acquired, err := p.concurrency.Acquire(ctx, client.ID)
+		if err != nil {
+			rateLimitDecision = "concurrency_error"
+			metrics.ConcurrencyDecisions.Inc("error")
+			log.Ctx(ctx).Error().Err(err).Str("client_id", client.ID).Msg("Concurrency limiter error")
+			statusCode = http.StatusInternalServerError
+			p.errorHandler(w, r, err)
+			return
+		}
+
+		if !acquired {
+			rateLimitDecision = "concurrency_denied"
+			metrics.ConcurrencyDecisions.Inc("denied")
+			log.Ctx(ctx).Warn().Str("client_id", client.ID).Msg("Concurrency limit exceeded")
+			statusCode = http.StatusTooManyRequests
+
+			w.Header().Set("Retry-After", retryAfter(p.typicalDuration()))
+
+			http.Error(w, "Too many concurrent requests", http.StatusTooManyRequests)
+			return
+		}
+
+		// Слот занимается до проверки скорости, чтобы запрос, отклонённый
+		// лимитом одновременных запросов, не расходовал токены. Освобождается
+		// он, когда ответ бэкенда полностью передан клиенту.
+		defer release()
+		metrics.ConcurrencyDecisions.Inc("acquired")
+	}
+
 	if p.rateLimiter != nil {
-		clientIP := getClientIP(r)
-		allowed, remaining, err := p.rateLimiter.Allow(r.Context(), clientIP, 1)
+		rateLimitCost = p.costRules.Cost(r)
+
+		_, rateLimitSpan := p.tracer.Start(ctx, "ratelimit.check", tracing.KindInternal)
+		result, err := p.rateLimiter.AllowRequest(ctx, ratelimit.Request{
+			ClientID: client.ID,
+			IP:       p.identity.RemoteIP(r),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Tokens:   rateLimitCost,
+		})
+		if errors.Is(err, ratelimit.ErrRateLimitExceeded) {
+			err = nil
+		}
+		rateLimitSpan.SetAttributes(
+			tracing.String("ratelimit.client_id", client.ID),
+			tracing.String("ratelimit.client_source", client.Source),
+			tracing.Bool("ratelimit.allowed", result.Allowed),
+			tracing.String("ratelimit.layer", result.Layer),
+			tracing.Int("ratelimit.cost", rateLimitCost),
+			tracing.Int("ratelimit.remaining", result.Remaining),
+		)
+		rateLimitSpan.SetError(err)
+		rateLimitSpan.End()
+
 		if err != nil {
-			log.Error().Err(err).Str("client_ip", clientIP).Msg("Rate limiter error")
+			rateLimitDecision = "error"
+			metrics.RateLimitDecisions.Inc("error")
+			log.Ctx(ctx).Error().Err(err).Str("client_id", client.ID).Msg("Rate limiter error")
 			statusCode = http.StatusInternalServerError
 			p.errorHandler(w, r, err)
 			return
 		}
 
-		if !allowed {
-			log.Warn().Str("client_ip", clientIP).Msg("Rate limit exceeded")
+		if !result.Allowed {
+			rateLimitDecision = "denied"
+			metrics.RateLimitDecisions.Inc("denied")
+			log.Ctx(ctx).Warn().Str("client_id", client.ID).Str("layer", result.Layer).Int("cost", rateLimitCost).Msg("Rate limit exceeded")
 			statusCode = http.StatusTooManyRequests
 
-			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-			w.Header().Set("Retry-After", "1")
+			p.setRateLimitHeaders(w.Header(), result, rateLimitCost)
+			w.Header().Set("Retry-After", retryAfter(result.RetryAfter))
 
 			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 			return
 		}
+
+		rateLimitDecision = "allowed"
+		metrics.RateLimitDecisions.Inc("allowed")
+		p.setRateLimitHeaders(w.Header(), result, rateLimitCost)
 	}
 
-	backend, err := p.balancer.NextBackend()
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get backend")
-		statusCode = http.StatusServiceUnavailable
-		p.errorHandler(w, r, err)
-		return
+	_, selectSpan := p.tracer.Start(ctx, "balancer.select", tracing.KindInternal)
+
+	var pinned bool
+	if p.sticky != nil {
+		backend = p.sticky.backend(r, p.balancer)
+		pinned = backend != nil
 	}
 
+	if backend == nil {
+		var err error
+		backend, err = p.balancer.NextBackend()
+		if err != nil {
+			selectSpan.SetError(err)
+			selectSpan.End()
+
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to get backend")
+			statusCode = http.StatusServiceUnavailable
+			p.errorHandler(w, r, err)
+			return
+		}
+	}
+
+	selectSpan.SetAttributes(
+		tracing.String("lb.algorithm", p.balancer.Name()),
+		tracing.String("lb.backend", backend.URL.String()),
+		tracing.Bool("lb.sticky", pinned),
+	)
+	selectSpan.End()
+
+	metrics.SetBackend(r.Context(), backend.URL.String())
+
 	backend.IncrementActiveConns()
 	defer backend.DecrementActiveConns()
 
+	upstreamCtx, upstreamSpan := p.tracer.Start(ctx, "HTTP "+r.Method, tracing.KindClient)
+	upstreamSpan.SetAttributes(
+		tracing.String("http.request.method", r.Method),
+		tracing.String("server.address", backend.URL.Host),
+		tracing.String("url.full", backend.URL.String()+r.URL.Path),
+	)
+	defer upstreamSpan.End()
+
 	proxy := httputil.NewSingleHostReverseProxy(backend.URL)
 
 	originalDirector := proxy.Director
 	proxy.Director = func(req *http.Request) {
 		originalDirector(req)
 
+		tracing.Inject(req.Context(), req.Header)
+
 		req.Header.Set("X-Forwarded-Host", req.Host)
 		req.Header.Set("X-Origin-Host", backend.URL.Host)
 		req.Header.Set("X-Proxy", "Go-Load-Balancer")
@@ -154,7 +361,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		log.Error().
+		log.Ctx(r.Context()).Error().
 			Err(err).
 			Str("backend", backend.URL.String()).
 			Str("path", r.URL.Path).
@@ -162,6 +369,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		statusCode = http.StatusBadGateway
 		responseErr = err
+		upstreamSpan.SetError(err)
 
 		backend.RecordRequest(false)
 		backend.IncrementFailureCount()
@@ -169,41 +377,83 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		p.errorHandler(w, r, err)
 	}
 
+	upstreamStart := time.Now()
+
 	proxy.ModifyResponse = func(resp *http.Response) error {
 		statusCode = resp.StatusCode
 
+		ttfb := time.Since(upstreamStart)
+		backend.Latency.RecordTTFB(ttfb)
+		p.latency.RecordTTFB(ttfb)
+
+		upstreamSpan.SetAttributes(tracing.Int("http.response.status_code", statusCode))
+		if statusCode >= 500 {
+			upstreamSpan.SetStatus(tracing.StatusError, http.StatusText(statusCode))
+		}
+
 		if statusCode >= 500 {
 			backend.RecordRequest(false)
 		} else {
 			backend.RecordRequest(true)
 		}
 
+		if p.sticky != nil && !pinned {
+			resp.Header.Add("Set-Cookie", p.sticky.cookie(backend, time.Now()).String())
+		}
+
 		return nil
 	}
 
-	proxy.ServeHTTP(w, r)
-}
+	proxy.ServeHTTP(w, r.WithContext(upstreamCtx))
 
-func getClientIP(r *http.Request) string {
+	total := time.Since(upstreamStart)
+	upstreamLatency = total
+	backend.Latency.RecordTotal(total)
+	p.latency.RecordTotal(total)
+}
 
-	forwardedFor := r.Header.Get("X-Forwarded-For")
-	if forwardedFor != "" {
+// LatencyStats возвращает распределение задержек запросов ко всем бэкендам.
+func (p *Proxy) LatencyStats() latency.Stats {
+	return p.latency.Stats()
+}
 
-		ips := strings.Split(forwardedFor, ",")
-		ip := strings.TrimSpace(ips[0])
-		return ip
-	}
+// Стили заголовков rate limiting (rate_limit.headers).
+const (
+	HeadersIETF   = "ietf"
+	HeadersLegacy = "legacy"
+	HeadersOff    = "off"
+)
 
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// setRateLimitHeaders сообщает клиенту состояние лимита: заголовками
+// RateLimit и RateLimit-Policy из черновика IETF или X-RateLimit-*.
+func (p *Proxy) setRateLimitHeaders(h http.Header, result ratelimit.Result, cost int) {
+	switch p.config.RateLimit.Headers {
+	case HeadersOff:
+		return
+	case HeadersLegacy:
+		h.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+	default:
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, ceilSeconds(result.Window)))
+		h.Set("RateLimit", fmt.Sprintf("limit=%d, remaining=%d, reset=%d",
+			result.Limit, result.Remaining, ceilSeconds(result.Reset)))
 	}
+	h.Set("X-RateLimit-Cost", strconv.Itoa(cost))
+}
 
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
+// typicalDuration возвращает медианное время запроса за последнюю минуту:
+// примерно через столько освобождается слот одновременного запроса.
+func (p *Proxy) typicalDuration() time.Duration {
+	return time.Duration(p.latency.Stats().Total.Last1m.P50 * float64(time.Millisecond))
+}
 
-		return r.RemoteAddr
-	}
+// retryAfter переводит задержку в секунды для заголовка Retry-After. Если
+// лимитер задержку не оценил, клиенту предлагается повторить через секунду.
+func retryAfter(d time.Duration) string {
+	return strconv.FormatInt(max(ceilSeconds(d), 1), 10)
+}
 
-	return ip
+func ceilSeconds(d time.Duration) int64 {
+	return int64((max(d, 0) + time.Second - 1) / time.Second)
 }
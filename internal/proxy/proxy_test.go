@@ -0,0 +1,479 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/accesslog"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/identity"
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
+	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+	"go-cloud-camp-2025-test-assignment/internal/tracing"
+)
+
+func TestProxy_TracePropagation(t *testing.T) {
+	var exported int
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exported++
+	}))
+	defer collector.Close()
+
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	tracer := tracing.NewTracer(config.TracingConfig{
+		Enabled:       true,
+		Endpoint:      collector.URL,
+		ServiceName:   "lb",
+		SampleRatio:   1,
+		BatchSize:     16,
+		FlushInterval: time.Hour,
+		Timeout:       time.Second,
+	})
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg, WithTracer(tracer))
+
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", incoming)
+	p.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	sc, ok := tracing.ParseTraceparent(upstreamTraceparent)
+	if !ok {
+		t.Fatalf("upstream traceparent = %q, want valid header", upstreamTraceparent)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("upstream trace id = %s, want incoming trace", sc.TraceID)
+	}
+	if upstreamTraceparent == incoming {
+		t.Errorf("upstream traceparent should reference the balancer's client span")
+	}
+	if exported != 1 {
+		t.Errorf("collector received %d export requests, want 1", exported)
+	}
+}
+
+func TestProxy_AccessLog(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	accessLog, err := accesslog.New(config.AccessLogConfig{Format: accesslog.FormatJSON, Output: "file", FilePath: path})
+	if err != nil {
+		t.Fatalf("Failed to create access log: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg, WithAccessLog(accessLog))
+
+	p.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items", strings.NewReader("payload")))
+	accessLog.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read access log: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("access log entry is not JSON: %v\n%s", err, data)
+	}
+
+	if entry["bytes_in"] != float64(7) || entry["bytes_out"] != float64(5) {
+		t.Errorf("access log bytes in/out = %v/%v, want 7/5", entry["bytes_in"], entry["bytes_out"])
+	}
+	if entry["upstream"] != backend.URL.Host || entry["status"] != float64(200) {
+		t.Errorf("access log entry = %v", entry)
+	}
+	if _, ok := entry["upstream_latency_ms"]; !ok {
+		t.Errorf("access log entry should contain upstream latency")
+	}
+}
+
+func TestProxy_ConcurrencyLimit(t *testing.T) {
+	unblock := make(chan struct{})
+	started := make(chan struct{}, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-unblock
+		}
+	}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	rateLimitCfg := &config.RateLimitConfig{
+		Concurrency: config.ConcurrencyConfig{MaxInFlight: 1, LeaseTTL: time.Minute},
+	}
+	limiter := ratelimit.NewConcurrencyLimiter(storage.NewMemoryStorage(), rateLimitCfg)
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg, WithConcurrencyLimiter(limiter))
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		done <- rec.Code
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Concurrent request status = %d, want 429", rec.Code)
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Slow request status = %d, want 200", code)
+	}
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Request after release status = %d, want 200", rec.Code)
+	}
+}
+
+func TestProxy_ConcurrencyDenyKeepsTokens(t *testing.T) {
+	unblock := make(chan struct{})
+	started := make(chan struct{}, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-unblock
+		}
+	}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	rateLimitCfg := &config.RateLimitConfig{
+		Default:     config.TokenBucketConfig{Capacity: 2, RefillRate: 1},
+		Concurrency: config.ConcurrencyConfig{MaxInFlight: 1, LeaseTTL: time.Minute},
+	}
+	limiter, err := ratelimit.NewRateLimiter(store, rateLimitCfg)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg,
+		WithRateLimiter(limiter), WithConcurrencyLimiter(ratelimit.NewConcurrencyLimiter(store, rateLimitCfg)))
+
+	// Retry-After отказа по одновременным запросам - медианное время запроса.
+	p.latency.RecordTotal(2500 * time.Millisecond)
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		done <- rec.Code
+	}()
+	<-started
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "3" {
+			t.Errorf("Concurrent request status = %d, Retry-After = %q, want 429 and 3", rec.Code, rec.Header().Get("Retry-After"))
+		}
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Slow request status = %d, want 200", code)
+	}
+
+	// Отклонённые по одновременным запросам не списали токены: второй из
+	// двух токенов ещё доступен.
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Request after release status = %d, want 200", rec.Code)
+	}
+}
+
+func TestProxy_RequestCost(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	rateLimitCfg := &config.RateLimitConfig{
+		Default: config.TokenBucketConfig{Capacity: 10, RefillRate: 1},
+		Cost: config.CostConfig{
+			Default: 1,
+			Rules:   []config.CostRuleConfig{{Method: http.MethodPost, Path: "/upload", Cost: 6}},
+		},
+	}
+	limiter, err := ratelimit.NewRateLimiter(storage.NewMemoryStorage(), rateLimitCfg)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg,
+		WithRateLimiter(limiter), WithCostRules(ratelimit.NewCostRules(rateLimitCfg.Cost)))
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Cost") != "6" {
+		t.Fatalf("Upload status = %d, cost = %q, want 200 and 6", rec.Code, rec.Header().Get("X-RateLimit-Cost"))
+	}
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Second upload status = %d, want 429", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Cost") != "1" {
+		t.Errorf("Cheap request status = %d, cost = %q, want 200 and 1", rec.Code, rec.Header().Get("X-RateLimit-Cost"))
+	}
+}
+
+func TestProxy_RateLimitHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	// Запрос стоимостью 10 опустошает бакет ёмкостью 10, который пополняется
+	// на 2 токена в секунду, поэтому следующий запрос возможен через 5 секунд.
+	tests := []struct {
+		style   string
+		allowed map[string]string
+	}{
+		{HeadersIETF, map[string]string{"RateLimit": "limit=10, remaining=0, reset=5", "RateLimit-Policy": "10;w=5"}},
+		{HeadersLegacy, map[string]string{"X-RateLimit-Limit": "10", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "5"}},
+		{HeadersOff, map[string]string{"RateLimit": "", "X-RateLimit-Remaining": "", "X-RateLimit-Cost": ""}},
+	}
+
+	for _, tt := range tests {
+		rateLimitCfg := config.RateLimitConfig{
+			Default: config.TokenBucketConfig{Capacity: 10, RefillRate: 2},
+			Cost:    config.CostConfig{Default: 10},
+			Headers: tt.style,
+		}
+		limiter, err := ratelimit.NewRateLimiter(storage.NewMemoryStorage(), &rateLimitCfg)
+		if err != nil {
+			t.Fatalf("Failed to create rate limiter: %v", err)
+		}
+
+		cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}, RateLimit: rateLimitCfg}
+		p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg,
+			WithRateLimiter(limiter), WithCostRules(ratelimit.NewCostRules(rateLimitCfg.Cost)))
+
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: first request status = %d, want 200", tt.style, rec.Code)
+		}
+		for header, want := range tt.allowed {
+			if got := rec.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.style, header, got, want)
+			}
+		}
+
+		rec = httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "5" {
+			t.Errorf("%s: second request status = %d, Retry-After = %q, want 429 and 5",
+				tt.style, rec.Code, rec.Header().Get("Retry-After"))
+		}
+
+		limiter.Close()
+	}
+}
+
+func TestProxy_ClientIdentity(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	rateLimitCfg := &config.RateLimitConfig{Default: config.TokenBucketConfig{Capacity: 1, RefillRate: 1}}
+	limiter, err := ratelimit.NewRateLimiter(storage.NewMemoryStorage(), rateLimitCfg)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	resolver, err := identity.NewResolver([]config.IdentityConfig{{Type: identity.TypeHeader, Name: "X-API-Key"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg,
+		WithRateLimiter(limiter), WithIdentity(resolver))
+
+	request := func(key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	// Клиенты за одним IP различаются по ключу API.
+	if code := request("key1"); code != http.StatusOK {
+		t.Fatalf("key1 status = %d, want 200", code)
+	}
+	if code := request("key1"); code != http.StatusTooManyRequests {
+		t.Errorf("Second key1 status = %d, want 429", code)
+	}
+	if code := request("key2"); code != http.StatusOK {
+		t.Errorf("key2 status = %d, want 200", code)
+	}
+	if code := request(""); code != http.StatusOK {
+		t.Errorf("Request without key status = %d, want 200", code)
+	}
+}
+
+func TestProxy_CIDRSpoofedForwardedFor(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	rateLimitCfg := &config.RateLimitConfig{
+		Default:        config.TokenBucketConfig{Capacity: 1, RefillRate: 1},
+		CIDRs:          []config.CIDRLimitConfig{{CIDR: "10.0.0.0/8", Capacity: 5, RefillRate: 1}},
+		TrustedProxies: []string{"198.51.100.0/24"},
+	}
+	limiter, err := ratelimit.NewRateLimiter(storage.NewMemoryStorage(), rateLimitCfg)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	resolver, err := identity.NewResolver([]config.IdentityConfig{{Type: identity.TypeHeader, Name: "X-API-Key"}}, rateLimitCfg.TrustedProxies)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{Timeout: time.Second}}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg,
+		WithRateLimiter(limiter), WithIdentity(resolver))
+
+	allowed := func(key, remoteAddr string) int {
+		var n int
+		for i := 0; i < 5; i++ {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = remoteAddr
+			r.Header.Set("X-API-Key", key)
+			r.Header.Set("X-Forwarded-For", "10.0.0.1")
+			rec := httptest.NewRecorder()
+			p.ServeHTTP(rec, r)
+			if rec.Code == http.StatusOK {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Клиент сам выставил адрес из внутренней сети: лимит диапазона не применяется.
+	if n := allowed("key1", "203.0.113.5:1234"); n != 1 {
+		t.Errorf("Spoofed X-Forwarded-For allowed %d requests, want default capacity 1", n)
+	}
+	// Тот же заголовок от доверенного прокси даёт лимит диапазона.
+	if n := allowed("key2", "198.51.100.1:1234"); n != 5 {
+		t.Errorf("Trusted proxy allowed %d requests, want cidr capacity 5", n)
+	}
+}
+
+func TestProxy_RouteMetrics(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	backend, err := balancer.NewBackend(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Timeout: time.Second},
+		RateLimit: config.RateLimitConfig{
+			Routes: []config.RouteLimitConfig{{Method: http.MethodPost, Path: "/upload", Capacity: 10, RefillRate: 1}},
+		},
+	}
+	p := NewProxy(balancer.NewRoundRobinBalancer([]*balancer.Backend{backend}), cfg)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", p)
+	handler := metrics.Instrument(mux)
+
+	routeBefore := metrics.Requests.Value("POST /upload", upstream.URL, "2xx")
+	rootBefore := metrics.Requests.Value("/", upstream.URL, "2xx")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/upload", nil))
+
+	if got := metrics.Requests.Value("POST /upload", upstream.URL, "2xx"); got != routeBefore+1 {
+		t.Errorf("Requests for route POST /upload = %d, want %d", got, routeBefore+1)
+	}
+	if got := metrics.Requests.Value("/", upstream.URL, "2xx"); got != rootBefore+1 {
+		t.Errorf("Requests without matching route = %d, want %d", got, rootBefore+1)
+	}
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+)
+
+// stickySessions привязывает клиента к бэкенду через cookie вида
+// "<id бэкенда>.<срок действия>.<подпись>". Вместо адреса бэкенда в cookie
+// хранится хеш его URL, подпись HMAC-SHA256 защищает от подмены.
+type stickySessions struct {
+	cfg config.StickyConfig
+	key []byte
+}
+
+func newStickySessions(cfg config.StickyConfig) *stickySessions {
+	return &stickySessions{
+		cfg: cfg,
+		key: []byte(cfg.Secret),
+	}
+}
+
+// backend возвращает бэкенд из cookie запроса, если подпись верна, срок не
+// истёк и бэкенд может принимать запросы. Иначе возвращается nil и бэкенд
+// выбирается алгоритмом балансировки.
+func (s *stickySessions) backend(r *http.Request, lb balancer.Balancer) *balancer.Backend {
+	cookie, err := r.Cookie(s.cfg.CookieName)
+	if err != nil {
+		return nil
+	}
+
+	id, ok := s.verify(cookie.Value, time.Now())
+	if !ok {
+		return nil
+	}
+
+	for _, backend := range lb.GetAllBackends() {
+		if backendID(backend) != id {
+			continue
+		}
+		if !backend.IsAvailable() || backend.Draining() || !backend.Enabled() {
+			return nil
+		}
+		return backend
+	}
+
+	return nil
+}
+
+func (s *stickySessions) cookie(backend *balancer.Backend, now time.Time) *http.Cookie {
+	expires := now.Add(s.cfg.TTL)
+
+	return &http.Cookie{
+		Name:     s.cfg.CookieName,
+		Value:    s.sign(backendID(backend), expires),
+		Path:     s.cfg.Path,
+		Expires:  expires,
+		MaxAge:   int(s.cfg.TTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (s *stickySessions) sign(id string, expires time.Time) string {
+	payload := id + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + s.signature(payload)
+}
+
+func (s *stickySessions) verify(value string, now time.Time) (string, bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return "", false
+	}
+	payload, signature := value[:i], value[i+1:]
+
+	if !hmac.Equal([]byte(signature), []byte(s.signature(payload))) {
+		return "", false
+	}
+
+	id, expiresStr, ok := strings.Cut(payload, ".")
+	if !ok {
+		return "", false
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return "", false
+	}
+
+	return id, true
+}
+
+func (s *stickySessions) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func backendID(backend *balancer.Backend) string {
+	sum := sha256.Sum256([]byte(backend.URL.String()))
+	return hex.EncodeToString(sum[:8])
+}
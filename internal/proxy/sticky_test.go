@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+)
+
+func newStickyTestProxy(t *testing.T) (*Proxy, []*balancer.Backend) {
+	t.Helper()
+
+	var backends []*balancer.Backend
+	for _, name := range []string{"first", "second"} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+		t.Cleanup(server.Close)
+
+		backend, err := balancer.NewBackend(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to create backend: %v", err)
+		}
+		backends = append(backends, backend)
+	}
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Timeout: time.Second},
+		Sticky: config.StickyConfig{
+			Enabled:    true,
+			CookieName: "lb_affinity",
+			TTL:        time.Hour,
+			Path:       "/",
+			Secret:     "test-secret",
+		},
+	}
+
+	return NewProxy(balancer.NewRoundRobinBalancer(backends), cfg), backends
+}
+
+func serve(p *Proxy, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	return rec
+}
+
+func stickyCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "lb_affinity" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestProxy_StickySession(t *testing.T) {
+	p, backends := newStickyTestProxy(t)
+
+	first := serve(p, nil)
+	cookie := stickyCookie(first)
+	if cookie == nil {
+		t.Fatalf("first response should set affinity cookie")
+	}
+	pinnedTo := first.Body.String()
+
+	for i := 0; i < 5; i++ {
+		rec := serve(p, cookie)
+		if rec.Body.String() != pinnedTo {
+			t.Fatalf("request with cookie went to %q, want %q", rec.Body.String(), pinnedTo)
+		}
+		if stickyCookie(rec) != nil {
+			t.Errorf("pinned request should not reissue cookie")
+		}
+	}
+
+	for _, backend := range backends {
+		if backendID(backend) == cookie.Value[:16] {
+			backend.MarkDown()
+		}
+	}
+
+	rec := serve(p, cookie)
+	if rec.Body.String() == pinnedTo {
+		t.Errorf("request should fall back to another backend when pinned one is down")
+	}
+	if stickyCookie(rec) == nil {
+		t.Errorf("fallback response should issue a new cookie")
+	}
+}
+
+func TestStickySessions_Verify(t *testing.T) {
+	s := newStickySessions(config.StickyConfig{CookieName: "lb_affinity", TTL: time.Minute, Secret: "secret"})
+	now := time.Now()
+	value := s.sign("abc", now.Add(time.Minute))
+
+	tests := []struct {
+		name  string
+		value string
+		now   time.Time
+		want  bool
+	}{
+		{name: "Valid", value: value, now: now, want: true},
+		{name: "Expired", value: value, now: now.Add(2 * time.Minute), want: false},
+		{name: "Tampered backend", value: "abd" + value[3:], now: now, want: false},
+		{name: "Other key", value: newStickySessions(config.StickyConfig{Secret: "other"}).sign("abc", now.Add(time.Minute)), now: now, want: false},
+		{name: "Malformed", value: "garbage", now: now, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := s.verify(tt.value, tt.now)
+			if ok != tt.want {
+				t.Errorf("verify() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && id != "abc" {
+				t.Errorf("verify() id = %s, want abc", id)
+			}
+		})
+	}
+}
@@ -2,30 +2,34 @@ package ratelimit
 
 import (
 	"encoding/json"
-	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/identity"
 	"go-cloud-camp-2025-test-assignment/internal/storage"
-	"net"
 	"net/http"
-
-	"github.com/rs/zerolog/log"
+	"net/netip"
 )
 
 type ClientManager struct {
-	storage       storage.Storage
-	rateLimiter   *TokenBucketRateLimiter
-	defaultConfig config.TokenBucketConfig
+	storage     storage.Storage
+	rateLimiter ClientRateLimiter
+	concurrency *ConcurrencyLimiter
+	identity    *identity.Resolver
 }
 
 type ClientConfigRequest struct {
-	ClientID   string `json:"client_id"`
-	Capacity   int    `json:"capacity"`
-	RefillRate int    `json:"refill_rate"`
+	ClientID    string `json:"client_id"`
+	Group       string `json:"group"`
+	Capacity    int    `json:"capacity"`
+	RefillRate  int    `json:"refill_rate"`
+	MaxInFlight int    `json:"max_in_flight"`
 }
 
 type ClientConfigResponse struct {
-	ClientID   string `json:"client_id"`
-	Capacity   int    `json:"capacity"`
-	RefillRate int    `json:"refill_rate"`
+	ClientID    string `json:"client_id"`
+	Group       string `json:"group,omitempty"`
+	Policy      string `json:"policy"`
+	Capacity    int    `json:"capacity"`
+	RefillRate  int    `json:"refill_rate"`
+	MaxInFlight int    `json:"max_in_flight"`
 }
 
 type ErrorResponse struct {
@@ -33,11 +37,15 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
-func NewClientManager(store storage.Storage, limiter *TokenBucketRateLimiter, cfg *config.RateLimitConfig) *ClientManager {
+// NewClientManager создаёт API управления клиентами. resolver определяет
+// клиента в /client-status так же, как прокси, чтобы client_id в API
+// совпадали с ключами лимитов.
+func NewClientManager(store storage.Storage, limiter ClientRateLimiter, concurrency *ConcurrencyLimiter, resolver *identity.Resolver) *ClientManager {
 	return &ClientManager{
-		storage:       store,
-		rateLimiter:   limiter,
-		defaultConfig: cfg.Default,
+		storage:     store,
+		rateLimiter: limiter,
+		concurrency: concurrency,
+		identity:    resolver,
 	}
 }
 
@@ -49,7 +57,7 @@ func (cm *ClientManager) HandleAddClient(w http.ResponseWriter, r *http.Request)
 
 	var req ClientConfigRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Error().Err(err).Msg("Failed to decode client config request")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to decode client config request")
 		sendErrorResponse(w, http.StatusBadRequest, "Invalid request format")
 		return
 	}
@@ -59,35 +67,87 @@ func (cm *ClientManager) HandleAddClient(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.Capacity <= 0 {
-		req.Capacity = cm.defaultConfig.Capacity
+	if req.MaxInFlight < 0 {
+		sendErrorResponse(w, http.StatusBadRequest, "max_in_flight must not be negative")
+		return
+	}
+
+	if req.Group != "" && !cm.rateLimiter.HasGroup(req.Group) {
+		sendErrorResponse(w, http.StatusBadRequest, "Unknown group")
+		return
 	}
-	if req.RefillRate <= 0 {
-		req.RefillRate = cm.defaultConfig.RefillRate
+
+	// Клиенту в группе собственный лимит задаётся, только если он указан явно.
+	// Без группы, как и раньше, недостающие значения берутся по умолчанию.
+	if req.Group != "" && req.Capacity <= 0 && req.RefillRate <= 0 {
+		if err := cm.rateLimiter.DeleteClientConfig(r.Context(), req.ClientID); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to reset client config")
+			sendErrorResponse(w, http.StatusInternalServerError, "Failed to update client configuration")
+			return
+		}
+	} else {
+		defaults := cm.rateLimiter.DefaultConfig()
+		if req.Capacity <= 0 {
+			req.Capacity = defaults.Capacity
+		}
+		if req.RefillRate <= 0 {
+			req.RefillRate = defaults.RefillRate
+		}
+
+		if err := cm.rateLimiter.UpdateClientConfig(r.Context(), req.ClientID, req.Capacity, req.RefillRate); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to update client config")
+			sendErrorResponse(w, http.StatusInternalServerError, "Failed to update client configuration")
+			return
+		}
+	}
+
+	if err := cm.rateLimiter.UpdateClientGroup(r.Context(), req.ClientID, req.Group); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to update client group")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to update client configuration")
+		return
 	}
 
-	if err := cm.rateLimiter.UpdateClientConfig(r.Context(), req.ClientID, req.Capacity, req.RefillRate); err != nil {
-		log.Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to update client config")
+	if err := cm.concurrency.UpdateClientConfig(r.Context(), req.ClientID, req.MaxInFlight); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to update client concurrency limit")
 		sendErrorResponse(w, http.StatusInternalServerError, "Failed to update client configuration")
 		return
 	}
 
+	maxInFlight, err := cm.concurrency.MaxInFlight(r.Context(), req.ClientID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to get client concurrency limit")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client configuration")
+		return
+	}
+
+	policy, err := cm.rateLimiter.ResolvePolicy(r.Context(), req.ClientID, "")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to resolve client policy")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client configuration")
+		return
+	}
+
 	resp := ClientConfigResponse{
-		ClientID:   req.ClientID,
-		Capacity:   req.Capacity,
-		RefillRate: req.RefillRate,
+		ClientID:    req.ClientID,
+		Group:       req.Group,
+		Policy:      policy.Level,
+		Capacity:    policy.Capacity,
+		RefillRate:  policy.RefillRate,
+		MaxInFlight: maxInFlight,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Error().Err(err).Msg("Failed to encode response")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to encode response")
 	}
 
-	log.Info().
+	log.Ctx(r.Context()).Info().
 		Str("client_id", req.ClientID).
-		Int("capacity", req.Capacity).
-		Int("refill_rate", req.RefillRate).
+		Str("group", req.Group).
+		Int("capacity", policy.Capacity).
+		Int("refill_rate", policy.RefillRate).
+		Int("max_in_flight", maxInFlight).
 		Msg("Client configuration updated")
 }
 
@@ -103,28 +163,33 @@ func (cm *ClientManager) HandleGetClient(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	capacity, refillRate, err := cm.storage.GetClientConfig(r.Context(), clientID)
+	policy, err := cm.rateLimiter.ResolvePolicy(r.Context(), clientID, "")
 	if err != nil {
-		log.Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
 		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client configuration")
 		return
 	}
 
-	if capacity == 0 || refillRate == 0 {
-		capacity = cm.defaultConfig.Capacity
-		refillRate = cm.defaultConfig.RefillRate
+	maxInFlight, err := cm.concurrency.MaxInFlight(r.Context(), clientID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client concurrency limit")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client configuration")
+		return
 	}
 
 	resp := ClientConfigResponse{
-		ClientID:   clientID,
-		Capacity:   capacity,
-		RefillRate: refillRate,
+		ClientID:    clientID,
+		Group:       policyGroup(policy),
+		Policy:      policy.Level,
+		Capacity:    policy.Capacity,
+		RefillRate:  policy.RefillRate,
+		MaxInFlight: maxInFlight,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Error().Err(err).Msg("Failed to encode response")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to encode response")
 	}
 }
 
@@ -140,14 +205,20 @@ func (cm *ClientManager) HandleDeleteClient(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := cm.storage.SetClientConfig(r.Context(), clientID, 0, 0); err != nil {
-		log.Error().Err(err).Str("client_id", clientID).Msg("Failed to delete client config")
+	if err := cm.rateLimiter.DeleteClientConfig(r.Context(), clientID); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to delete client config")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to delete client configuration")
+		return
+	}
+
+	if err := cm.concurrency.UpdateClientConfig(r.Context(), clientID, 0); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to delete client concurrency limit")
 		sendErrorResponse(w, http.StatusInternalServerError, "Failed to delete client configuration")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-	log.Info().Str("client_id", clientID).Msg("Client configuration deleted")
+	log.Ctx(r.Context()).Info().Str("client_id", clientID).Msg("Client configuration deleted")
 }
 
 func (cm *ClientManager) RegisterHandlers(mux *http.ServeMux) {
@@ -173,51 +244,93 @@ func (cm *ClientManager) HandleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Диапазоны cidrs проверяются по IP запроса. Для явно заданного client_id
+	// IP вызывающего к клиенту не относится, поэтому его можно передать
+	// параметром ip; без него адресом считается сам client_id, что верно
+	// только для клиентов, определённых по IP.
 	clientID := r.URL.Query().Get("client_id")
-	if clientID == "" {
+	ip := r.URL.Query().Get("ip")
+	if ip != "" {
+		if _, err := netip.ParseAddr(ip); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "Invalid IP address")
+			return
+		}
+	}
 
-		clientID = getClientIP(r)
+	var source string
+	if clientID == "" {
+		client := cm.identity.Resolve(r)
+		clientID, source = client.ID, client.Source
+		if ip == "" {
+			ip = cm.identity.RemoteIP(r)
+		}
 	}
 
-	capacity, refillRate, err := cm.storage.GetClientConfig(r.Context(), clientID)
+	policy, err := cm.rateLimiter.ResolvePolicy(r.Context(), clientID, ip)
 	if err != nil {
-		log.Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
 		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client status")
 		return
 	}
+	capacity, refillRate := policy.Capacity, policy.RefillRate
 
-	if capacity == 0 || refillRate == 0 {
-		capacity = cm.defaultConfig.Capacity
-		refillRate = cm.defaultConfig.RefillRate
+	result, err := cm.rateLimiter.Allow(r.Context(), clientID, 0)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to get tokens remaining")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get tokens remaining")
+		return
 	}
 
-	_, remaining, err := cm.rateLimiter.Allow(r.Context(), clientID, 0)
+	maxInFlight, err := cm.concurrency.MaxInFlight(r.Context(), clientID)
 	if err != nil {
-		log.Error().Err(err).Str("client_id", clientID).Msg("Failed to get tokens remaining")
-		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get tokens remaining")
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client concurrency limit")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client status")
 		return
 	}
 
+	inFlight, err := cm.concurrency.InFlight(r.Context(), clientID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client in-flight requests")
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get client status")
+		return
+	}
+
+	type PolicyStatus struct {
+		Level string       `json:"level"`
+		Name  string       `json:"name,omitempty"`
+		Chain []PolicyStep `json:"chain"`
+	}
+
 	type ClientStatus struct {
-		ClientID         string `json:"client_id"`
-		Capacity         int    `json:"capacity"`
-		RefillRate       int    `json:"refill_rate"`
-		TokensRemaining  int    `json:"tokens_remaining"`
-		TokensPercentage int    `json:"tokens_percentage"`
+		ClientID         string       `json:"client_id"`
+		IdentitySource   string       `json:"identity_source,omitempty"`
+		IP               string       `json:"ip,omitempty"`
+		Policy           PolicyStatus `json:"policy"`
+		Capacity         int          `json:"capacity"`
+		RefillRate       int          `json:"refill_rate"`
+		TokensRemaining  int          `json:"tokens_remaining"`
+		TokensPercentage int          `json:"tokens_percentage"`
+		MaxInFlight      int          `json:"max_in_flight"`
+		InFlight         int          `json:"in_flight"`
 	}
 
 	status := ClientStatus{
 		ClientID:         clientID,
+		IdentitySource:   source,
+		IP:               ip,
+		Policy:           PolicyStatus{Level: policy.Level, Name: policy.Name, Chain: policy.Chain},
 		Capacity:         capacity,
 		RefillRate:       refillRate,
-		TokensRemaining:  remaining,
-		TokensPercentage: int(float64(remaining) / float64(capacity) * 100),
+		TokensRemaining:  result.Remaining,
+		TokensPercentage: int(float64(result.Remaining) / float64(capacity) * 100),
+		MaxInFlight:      maxInFlight,
+		InFlight:         inFlight,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		log.Error().Err(err).Msg("Failed to encode response")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to encode response")
 	}
 }
 
@@ -235,22 +348,12 @@ func sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
 	}
 }
 
-func getClientIP(r *http.Request) string {
-
-	forwardedFor := r.Header.Get("X-Forwarded-For")
-	if forwardedFor != "" {
-		return forwardedFor
+// policyGroup возвращает группу клиента из цепочки определения лимита.
+func policyGroup(policy Policy) string {
+	for _, step := range policy.Chain {
+		if step.Level == PolicyGroup {
+			return step.Name
+		}
 	}
-
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
-	}
-
-	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-
-	return remoteIP
+	return ""
 }
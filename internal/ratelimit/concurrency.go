@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+// releaseTimeout ограничивает освобождение слота: запрос к этому моменту
+// уже завершён, и его контекст может быть отменён.
+const releaseTimeout = 5 * time.Second
+
+// ConcurrencyLimiter ограничивает число одновременных запросов клиента.
+// Слоты хранятся в storage с истечением, чтобы слоты упавшего экземпляра
+// освобождались сами.
+type ConcurrencyLimiter struct {
+	storage   storage.Storage
+	defaultMu sync.RWMutex
+	defaults  config.ConcurrencyConfig
+	clientsMu sync.RWMutex
+	clients   map[string]int
+}
+
+func NewConcurrencyLimiter(store storage.Storage, cfg *config.RateLimitConfig) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		storage:  store,
+		defaults: cfg.Concurrency,
+		clients:  make(map[string]int),
+	}
+}
+
+// Acquire занимает слот клиента. Если лимит исчерпан, acquired = false.
+// Занятый слот освобождается вызовом release.
+func (cl *ConcurrencyLimiter) Acquire(ctx context.Context, clientID string) (release func(), acquired bool, err error) {
+	limit, err := cl.MaxInFlight(ctx, clientID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client max in-flight")
+		return nil, false, err
+	}
+
+	if limit <= 0 {
+		return func() {}, true, nil
+	}
+
+	leaseID := newLeaseID()
+	acquired, inFlight, err := cl.storage.AcquireLease(ctx, clientID, leaseID, limit, cl.DefaultConfig().LeaseTTL)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to acquire concurrency slot")
+		return nil, false, err
+	}
+
+	if !acquired {
+		log.Ctx(ctx).Debug().
+			Str("client_id", clientID).
+			Int("in_flight", inFlight).
+			Int("max_in_flight", limit).
+			Msg("Concurrency limit exceeded")
+		return nil, false, nil
+	}
+
+	release = func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+		defer cancel()
+
+		if err := cl.storage.ReleaseLease(releaseCtx, clientID, leaseID); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to release concurrency slot")
+		}
+	}
+
+	return release, true, nil
+}
+
+// MaxInFlight возвращает лимит одновременных запросов клиента: свой или по
+// умолчанию.
+func (cl *ConcurrencyLimiter) MaxInFlight(ctx context.Context, clientID string) (int, error) {
+	cl.clientsMu.RLock()
+	limit, ok := cl.clients[clientID]
+	cl.clientsMu.RUnlock()
+
+	if !ok {
+		var err error
+		limit, err = cl.storage.GetClientMaxInFlight(ctx, clientID)
+		if err != nil {
+			return 0, err
+		}
+
+		cl.clientsMu.Lock()
+		cl.clients[clientID] = limit
+		cl.clientsMu.Unlock()
+	}
+
+	if limit == 0 {
+		limit = cl.DefaultConfig().MaxInFlight
+	}
+
+	return limit, nil
+}
+
+func (cl *ConcurrencyLimiter) InFlight(ctx context.Context, clientID string) (int, error) {
+	return cl.storage.InFlight(ctx, clientID)
+}
+
+// UpdateClientConfig задаёт лимит клиента; 0 возвращает лимит по умолчанию.
+func (cl *ConcurrencyLimiter) UpdateClientConfig(ctx context.Context, clientID string, maxInFlight int) error {
+	if maxInFlight < 0 {
+		return errors.New("maxInFlight must not be negative")
+	}
+
+	if err := cl.storage.SetClientMaxInFlight(ctx, clientID, maxInFlight); err != nil {
+		return err
+	}
+
+	cl.clientsMu.Lock()
+	cl.clients[clientID] = maxInFlight
+	cl.clientsMu.Unlock()
+
+	log.Info().
+		Str("client_id", clientID).
+		Int("max_in_flight", maxInFlight).
+		Msg("Client concurrency limit updated")
+
+	return nil
+}
+
+func (cl *ConcurrencyLimiter) DefaultConfig() config.ConcurrencyConfig {
+	cl.defaultMu.RLock()
+	defer cl.defaultMu.RUnlock()
+
+	return cl.defaults
+}
+
+func (cl *ConcurrencyLimiter) UpdateDefaultConfig(cfg config.ConcurrencyConfig) {
+	cl.defaultMu.Lock()
+	cl.defaults = cfg
+	cl.defaultMu.Unlock()
+
+	log.Info().
+		Int("max_in_flight", cfg.MaxInFlight).
+		Dur("lease_ttl", cfg.LeaseTTL).
+		Msg("Default concurrency limit updated")
+}
+
+func newLeaseID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+func TestConcurrencyLimiter_Acquire(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Concurrency: config.ConcurrencyConfig{MaxInFlight: 2, LeaseTTL: time.Minute},
+	}
+	limiter := NewConcurrencyLimiter(storage.NewMemoryStorage(), cfg)
+	ctx := context.Background()
+
+	release1, ok, err := limiter.Acquire(ctx, "client1")
+	if err != nil || !ok {
+		t.Fatalf("First acquire failed: %v, %v", ok, err)
+	}
+	if _, ok, _ := limiter.Acquire(ctx, "client1"); !ok {
+		t.Fatal("Second acquire should succeed")
+	}
+	if _, ok, _ := limiter.Acquire(ctx, "client1"); ok {
+		t.Fatal("Third acquire should be rejected")
+	}
+	if _, ok, _ := limiter.Acquire(ctx, "client2"); !ok {
+		t.Error("Other client should not be affected")
+	}
+
+	if inFlight, _ := limiter.InFlight(ctx, "client1"); inFlight != 2 {
+		t.Errorf("Expected 2 in-flight requests, got %d", inFlight)
+	}
+
+	release1()
+	if _, ok, _ := limiter.Acquire(ctx, "client1"); !ok {
+		t.Error("Acquire should succeed after release")
+	}
+}
+
+func TestConcurrencyLimiter_ClientConfig(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Concurrency: config.ConcurrencyConfig{MaxInFlight: 0, LeaseTTL: time.Minute},
+	}
+	limiter := NewConcurrencyLimiter(storage.NewMemoryStorage(), cfg)
+	ctx := context.Background()
+
+	// Без лимита по умолчанию слоты не учитываются.
+	for i := 0; i < 5; i++ {
+		if _, ok, _ := limiter.Acquire(ctx, "client1"); !ok {
+			t.Fatalf("Acquire %d should succeed without limit", i)
+		}
+	}
+
+	if err := limiter.UpdateClientConfig(ctx, "client2", 1); err != nil {
+		t.Fatalf("UpdateClientConfig failed: %v", err)
+	}
+	if limit, _ := limiter.MaxInFlight(ctx, "client2"); limit != 1 {
+		t.Errorf("Expected client limit 1, got %d", limit)
+	}
+	if _, ok, _ := limiter.Acquire(ctx, "client2"); !ok {
+		t.Fatal("First acquire should succeed")
+	}
+	if _, ok, _ := limiter.Acquire(ctx, "client2"); ok {
+		t.Error("Second acquire should be rejected by client limit")
+	}
+
+	if err := limiter.UpdateClientConfig(ctx, "client2", -1); err == nil {
+		t.Error("Expected error for negative limit")
+	}
+}
+
+func TestConcurrencyLimiter_LeaseExpiry(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Concurrency: config.ConcurrencyConfig{MaxInFlight: 1, LeaseTTL: 20 * time.Millisecond},
+	}
+	limiter := NewConcurrencyLimiter(storage.NewMemoryStorage(), cfg)
+	ctx := context.Background()
+
+	// Слот не освобождается, как если бы экземпляр упал.
+	if _, ok, _ := limiter.Acquire(ctx, "client1"); !ok {
+		t.Fatal("First acquire should succeed")
+	}
+	if _, ok, _ := limiter.Acquire(ctx, "client1"); ok {
+		t.Fatal("Second acquire should be rejected while lease is active")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok, _ := limiter.Acquire(ctx, "client1"); !ok {
+		t.Error("Acquire should succeed after lease expiry")
+	}
+}
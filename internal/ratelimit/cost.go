@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+// CostRules вычисляет стоимость запроса в токенах, чтобы дорогие запросы
+// расходовали лимиты быстрее.
+type CostRules struct {
+	defaultCost int
+	header      string
+	rules       []config.CostRuleConfig
+}
+
+func NewCostRules(cfg config.CostConfig) *CostRules {
+	defaultCost := cfg.Default
+	if defaultCost <= 0 {
+		defaultCost = 1
+	}
+
+	return &CostRules{
+		defaultCost: defaultCost,
+		header:      cfg.Header,
+		rules:       cfg.Rules,
+	}
+}
+
+// Cost возвращает стоимость запроса. Без правил стоимость любого запроса - 1.
+func (c *CostRules) Cost(r *http.Request) int {
+	if c == nil {
+		return 1
+	}
+
+	if c.header != "" {
+		if value := r.Header.Get(c.header); value != "" {
+			if cost, err := strconv.Atoi(value); err == nil && cost > 0 {
+				return cost
+			}
+			log.Ctx(r.Context()).Warn().Str("header", c.header).Str("value", value).Msg("Invalid request cost header")
+		}
+	}
+
+	for _, rule := range c.rules {
+		if matchCostRule(rule, r) {
+			return rule.Cost
+		}
+	}
+
+	return c.defaultCost
+}
+
+func matchCostRule(rule config.CostRuleConfig, r *http.Request) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+		return false
+	}
+	if rule.Path != "" && !matchPath(rule.Path, r.URL.Path) {
+		return false
+	}
+	// Неизвестная длина (-1) не подходит под правило с минимальной длиной.
+	if rule.MinContentLength > 0 && r.ContentLength < rule.MinContentLength {
+		return false
+	}
+	if rule.Header != "" {
+		value := r.Header.Get(rule.Header)
+		if value == "" || (rule.HeaderValue != "" && value != rule.HeaderValue) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func TestCostRules_Cost(t *testing.T) {
+	rules := NewCostRules(config.CostConfig{
+		Default: 1,
+		Header:  "X-Request-Cost",
+		Rules: []config.CostRuleConfig{
+			{Method: "POST", Path: "/upload", MinContentLength: 1 << 20, Cost: 20},
+			{Method: "POST", Path: "/upload", Cost: 5},
+			{Path: "/api/*", Header: "X-Plan", HeaderValue: "batch", Cost: 3},
+		},
+	})
+
+	largeUpload := httptest.NewRequest("POST", "/upload", strings.NewReader(strings.Repeat("x", 2<<20)))
+	smallUpload := httptest.NewRequest("POST", "/upload", strings.NewReader("x"))
+	batch := httptest.NewRequest("GET", "/api/items", nil)
+	batch.Header.Set("X-Plan", "batch")
+	interactive := httptest.NewRequest("GET", "/api/items", nil)
+	interactive.Header.Set("X-Plan", "interactive")
+	upstream := httptest.NewRequest("POST", "/upload", strings.NewReader("x"))
+	upstream.Header.Set("X-Request-Cost", "7")
+	invalid := httptest.NewRequest("GET", "/", nil)
+	invalid.Header.Set("X-Request-Cost", "-1")
+
+	tests := []struct {
+		name string
+		cost int
+		got  int
+	}{
+		{"large upload", 20, rules.Cost(largeUpload)},
+		{"small upload", 5, rules.Cost(smallUpload)},
+		{"batch plan", 3, rules.Cost(batch)},
+		{"other plan", 1, rules.Cost(interactive)},
+		{"upstream header", 7, rules.Cost(upstream)},
+		{"invalid header", 1, rules.Cost(invalid)},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.cost {
+			t.Errorf("%s: expected cost %d, got %d", tt.name, tt.cost, tt.got)
+		}
+	}
+
+	var none *CostRules
+	if cost := none.Cost(smallUpload); cost != 1 {
+		t.Errorf("Expected cost 1 without rules, got %d", cost)
+	}
+}
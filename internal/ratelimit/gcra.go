@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+// GCRARateLimiter ограничивает скорость алгоритмом GCRA с параметрами token
+// bucket: refill_rate запросов в секунду и всплеск до capacity. Состояние
+// клиента - одно значение, поэтому время до следующего разрешённого запроса
+// известно точно.
+type GCRARateLimiter struct {
+	clientLimits
+}
+
+func NewGCRARateLimiter(store storage.Storage, cfg *config.RateLimitConfig) (*GCRARateLimiter, error) {
+	limiter := &GCRARateLimiter{
+		clientLimits: newClientLimits(store, cfg),
+	}
+
+	if err := store.Ping(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// AllowRequest проверяет только лимит клиента: общие лимиты поддерживаются
+// лишь алгоритмом token_bucket.
+func (g *GCRARateLimiter) AllowRequest(ctx context.Context, req Request) (Result, error) {
+	return g.allow(ctx, req.ClientID, req.IP, req.Tokens)
+}
+
+func (g *GCRARateLimiter) Allow(ctx context.Context, clientID string, tokens int) (Result, error) {
+	return g.allow(ctx, clientID, "", tokens)
+}
+
+func (g *GCRARateLimiter) allow(ctx context.Context, clientID, ip string, tokens int) (Result, error) {
+	if tokens <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	capacity, refillRate, err := g.getClientConfig(ctx, clientID, ip)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
+		return Result{}, err
+	}
+
+	decision, err := g.storage.GCRA(ctx, clientID, tokens, capacity, refillRate)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to apply GCRA")
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:    decision.Allowed,
+		Limit:      capacity,
+		Remaining:  decision.Remaining,
+		RetryAfter: decision.RetryAfter,
+		Reset:      decision.Reset,
+		Window:     refillWindow(capacity, refillRate),
+	}
+
+	if !result.Allowed {
+		log.Ctx(ctx).Debug().
+			Str("client_id", clientID).
+			Int("requested", tokens).
+			Int("remaining", result.Remaining).
+			Int("capacity", capacity).
+			Dur("retry_after", result.RetryAfter).
+			Msg("Rate limit exceeded")
+		return result, ErrRateLimitExceeded
+	}
+
+	log.Ctx(ctx).Debug().
+		Str("client_id", clientID).
+		Int("requested", tokens).
+		Int("remaining", result.Remaining).
+		Int("capacity", capacity).
+		Msg("Request allowed")
+
+	return result, nil
+}
+
+func (g *GCRARateLimiter) Close() error {
+	return nil
+}
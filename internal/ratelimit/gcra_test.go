@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+func TestGCRARateLimiter_Allow(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Algorithm: AlgorithmGCRA,
+		Default: config.TokenBucketConfig{
+			Capacity:   2,
+			RefillRate: 1,
+		},
+	}
+
+	limiter, err := NewRateLimiter(storage.NewMemoryStorage(), cfg)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	if _, ok := limiter.(*GCRARateLimiter); !ok {
+		t.Fatalf("Expected GCRA limiter, got %T", limiter)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if result, err := limiter.Allow(ctx, "client1", 1); err != nil || !result.Allowed {
+			t.Fatalf("Request %d should be allowed: %+v, %v", i, result, err)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, "client1", 1)
+	if !errors.Is(err, ErrRateLimitExceeded) || result.Allowed {
+		t.Fatalf("Expected request over the burst to be denied, got %+v, %v", result, err)
+	}
+	if result.RetryAfter <= 900*time.Millisecond || result.RetryAfter > time.Second {
+		t.Errorf("Expected retry after about one second, got %s", result.RetryAfter)
+	}
+	if result.Limit != 2 {
+		t.Errorf("Expected limit 2, got %d", result.Limit)
+	}
+}
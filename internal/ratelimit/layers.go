@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"strings"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+// Уровни лимитов, через которые проходит запрос.
+const (
+	LayerGlobal = "global"
+	LayerRoute  = "route"
+	LayerClient = "client"
+)
+
+// Request - запрос к лимитеру: клиент и его адрес для лимитов диапазонов,
+// метод и путь для лимитов маршрутов и стоимость в токенах.
+type Request struct {
+	ClientID string
+	IP       string
+	Method   string
+	Path     string
+	Tokens   int
+}
+
+// layers - общие для всех клиентов лимиты поверх лимита клиента:
+// глобальный бакет и бакеты маршрутов.
+type layers struct {
+	global config.TokenBucketConfig
+	routes []config.RouteLimitConfig
+}
+
+// layer - бакет общего лимита, подходящий под запрос.
+type layer struct {
+	name       string
+	key        string
+	capacity   int
+	refillRate int
+}
+
+func newLayers(cfg *config.RateLimitConfig) layers {
+	return layers{
+		global: cfg.Global,
+		routes: cfg.Routes,
+	}
+}
+
+func (l layers) empty() bool {
+	return l.global.Capacity <= 0 && len(l.routes) == 0
+}
+
+// match возвращает общие лимиты запроса: глобальный, если задан, и первый
+// подходящий маршрут.
+func (l layers) match(method, path string) []layer {
+	var matched []layer
+
+	if l.global.Capacity > 0 {
+		matched = append(matched, layer{
+			name:       LayerGlobal,
+			key:        storage.LayerKey(LayerGlobal),
+			capacity:   l.global.Capacity,
+			refillRate: l.global.RefillRate,
+		})
+	}
+
+	if route, ok := matchRoute(l.routes, method, path); ok {
+		name := LayerRoute + ":" + RouteName(route)
+		matched = append(matched, layer{
+			name:       name,
+			key:        storage.LayerKey(name),
+			capacity:   route.Capacity,
+			refillRate: route.RefillRate,
+		})
+	}
+
+	return matched
+}
+
+// MatchRoute возвращает имя первого из routes, подходящего запросу, или
+// пустую строку. Имя совпадает с именем уровня маршрута без префикса
+// LayerRoute, например "POST /upload".
+func MatchRoute(routes []config.RouteLimitConfig, method, path string) string {
+	route, ok := matchRoute(routes, method, path)
+	if !ok {
+		return ""
+	}
+	return RouteName(route)
+}
+
+// RouteName - имя маршрута: метод, если задан, и шаблон пути.
+func RouteName(route config.RouteLimitConfig) string {
+	return strings.TrimSpace(strings.ToUpper(route.Method) + " " + route.Path)
+}
+
+func matchRoute(routes []config.RouteLimitConfig, method, path string) (config.RouteLimitConfig, bool) {
+	for _, route := range routes {
+		if route.Method != "" && !strings.EqualFold(route.Method, method) {
+			continue
+		}
+		if matchPath(route.Path, path) {
+			return route, true
+		}
+	}
+	return config.RouteLimitConfig{}, false
+}
+
+// matchPath сравнивает путь с шаблоном: точное совпадение или, если шаблон
+// оканчивается на "*", совпадение префикса.
+func matchPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == pattern
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+func TestTokenBucket_Layers(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Default: config.TokenBucketConfig{Capacity: 10, RefillRate: 1},
+		Global:  config.TokenBucketConfig{Capacity: 5, RefillRate: 1},
+		Routes: []config.RouteLimitConfig{
+			{Method: "POST", Path: "/upload", Capacity: 2, RefillRate: 1},
+		},
+	}
+	store := storage.NewMemoryStorage()
+	limiter, err := NewTokenBucketRateLimiter(store, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+	ctx := context.Background()
+
+	upload := Request{ClientID: "client1", Method: "POST", Path: "/upload", Tokens: 1}
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.AllowRequest(ctx, upload); err != nil {
+			t.Fatalf("Upload %d should be allowed: %v", i, err)
+		}
+	}
+
+	result, err := limiter.AllowRequest(ctx, upload)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("Expected route limit to reject, got %v", err)
+	}
+	if result.Layer != "route:POST /upload" || result.Limit != 2 {
+		t.Errorf("Expected route layer with limit 2, got %q with limit %d", result.Layer, result.Limit)
+	}
+
+	// Отклонённый маршрутом запрос не должен списать токены с глобального
+	// бакета и бакета клиента.
+	for i := 0; i < 3; i++ {
+		result, err := limiter.AllowRequest(ctx, Request{ClientID: "client1", Method: "GET", Path: "/", Tokens: 1})
+		if err != nil {
+			t.Fatalf("Request %d should be allowed: %v", i, err)
+		}
+		if i == 2 && (result.Layer != LayerGlobal || result.Remaining != 0) {
+			t.Errorf("Expected global layer with 0 remaining, got %q with %d", result.Layer, result.Remaining)
+		}
+	}
+
+	result, err = limiter.AllowRequest(ctx, Request{ClientID: "client2", Method: "GET", Path: "/", Tokens: 1})
+	if !errors.Is(err, ErrRateLimitExceeded) || result.Layer != LayerGlobal {
+		t.Fatalf("Expected global limit to reject other client, got %q, %v", result.Layer, err)
+	}
+
+	// Бакет клиента тоже не тронут отклонёнными запросами: 10 - 5 разрешённых.
+	client, err := store.TakeTokens(ctx, "client1", 0, 10, 1)
+	if err != nil {
+		t.Fatalf("TakeTokens failed: %v", err)
+	}
+	if client.Remaining != 5 {
+		t.Errorf("Expected 5 client tokens left, got %d", client.Remaining)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/upload", "/upload", true},
+		{"/upload", "/upload/1", false},
+		{"/api/*", "/api/users", true},
+		{"/api/*", "/apix", false},
+		{"*", "/anything", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	routes := []config.RouteLimitConfig{
+		{Method: "post", Path: "/upload", Capacity: 1, RefillRate: 1},
+		{Path: "/api/*", Capacity: 1, RefillRate: 1},
+	}
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"POST", "/upload", "POST /upload"},
+		{"GET", "/upload", ""},
+		{"GET", "/api/users", "/api/*"},
+		{"GET", "/", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MatchRoute(routes, tt.method, tt.path); got != tt.want {
+			t.Errorf("MatchRoute(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewRateLimiter_LayersRequireTokenBucket(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Algorithm: AlgorithmGCRA,
+		Default:   config.TokenBucketConfig{Capacity: 10, RefillRate: 1},
+		Global:    config.TokenBucketConfig{Capacity: 100, RefillRate: 10},
+	}
+	if _, err := NewRateLimiter(storage.NewMemoryStorage(), cfg); err == nil {
+		t.Error("Expected error for global limit with gcra algorithm")
+	}
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"cmp"
+	"errors"
+	"net/netip"
+	"slices"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+var ErrUnknownGroup = errors.New("unknown rate limit group")
+
+// Уровни, на которых определяется лимит клиента, в порядке приоритета.
+const (
+	PolicyOverride = "override"
+	PolicyGroup    = "group"
+	PolicyCIDR     = "cidr"
+	PolicyDefault  = "default"
+)
+
+// Policy - действующий лимит клиента. Chain содержит все уровни по порядку:
+// какие подошли клиенту и какой из них применён.
+type Policy struct {
+	Capacity   int
+	RefillRate int
+	Level      string
+	Name       string
+	Chain      []PolicyStep
+}
+
+type PolicyStep struct {
+	Level      string `json:"level"`
+	Name       string `json:"name,omitempty"`
+	Matched    bool   `json:"matched"`
+	Applied    bool   `json:"applied"`
+	Capacity   int    `json:"capacity,omitempty"`
+	RefillRate int    `json:"refill_rate,omitempty"`
+}
+
+// policies - лимиты групп и диапазонов адресов из конфигурации.
+type policies struct {
+	groups map[string]config.TokenBucketConfig
+	// cidrs отсортированы от самых узких диапазонов к широким, поэтому
+	// первый подходящий - с самым длинным префиксом.
+	cidrs []cidrPolicy
+}
+
+type cidrPolicy struct {
+	prefix netip.Prefix
+	limit  config.TokenBucketConfig
+}
+
+func newPolicies(cfg *config.RateLimitConfig) policies {
+	p := policies{groups: cfg.Groups}
+
+	for _, c := range cfg.CIDRs {
+		prefix, err := netip.ParsePrefix(c.CIDR)
+		if err != nil {
+			log.Warn().Err(err).Str("cidr", c.CIDR).Msg("Skipping invalid CIDR rate limit")
+			continue
+		}
+		p.cidrs = append(p.cidrs, cidrPolicy{
+			prefix: prefix.Masked(),
+			limit:  config.TokenBucketConfig{Capacity: c.Capacity, RefillRate: c.RefillRate},
+		})
+	}
+
+	slices.SortStableFunc(p.cidrs, func(a, b cidrPolicy) int {
+		return cmp.Compare(b.prefix.Bits(), a.prefix.Bits())
+	})
+
+	return p
+}
+
+func (p policies) group(name string) (config.TokenBucketConfig, bool) {
+	if name == "" {
+		return config.TokenBucketConfig{}, false
+	}
+	limit, ok := p.groups[name]
+	return limit, ok
+}
+
+// cidr возвращает самый узкий диапазон, в который входит адрес.
+func (p policies) cidr(ip string) (cidrPolicy, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return cidrPolicy{}, false
+	}
+	addr = addr.Unmap()
+
+	for _, c := range p.cidrs {
+		if c.prefix.Contains(addr) {
+			return c, true
+		}
+	}
+
+	return cidrPolicy{}, false
+}
+
+// resolve выбирает лимит клиента: собственный, группы, диапазона адресов
+// или по умолчанию.
+func (p policies) resolve(stored clientConfig, ip string, defaults config.TokenBucketConfig) Policy {
+	chain := make([]PolicyStep, 0, 4)
+
+	override := PolicyStep{Level: PolicyOverride}
+	if !stored.inherited {
+		override.Matched = true
+		override.Capacity, override.RefillRate = stored.capacity, stored.refillRate
+	}
+	chain = append(chain, override)
+
+	group := PolicyStep{Level: PolicyGroup, Name: stored.group}
+	if limit, ok := p.group(stored.group); ok {
+		group.Matched = true
+		group.Capacity, group.RefillRate = limit.Capacity, limit.RefillRate
+	}
+	chain = append(chain, group)
+
+	cidr := PolicyStep{Level: PolicyCIDR}
+	if c, ok := p.cidr(ip); ok {
+		cidr.Matched = true
+		cidr.Name = c.prefix.String()
+		cidr.Capacity, cidr.RefillRate = c.limit.Capacity, c.limit.RefillRate
+	}
+	chain = append(chain, cidr)
+
+	chain = append(chain, PolicyStep{
+		Level:      PolicyDefault,
+		Matched:    true,
+		Capacity:   defaults.Capacity,
+		RefillRate: defaults.RefillRate,
+	})
+
+	var policy Policy
+	for i := range chain {
+		if chain[i].Matched {
+			chain[i].Applied = true
+			policy = Policy{
+				Capacity:   chain[i].Capacity,
+				RefillRate: chain[i].RefillRate,
+				Level:      chain[i].Level,
+				Name:       chain[i].Name,
+			}
+			break
+		}
+	}
+	policy.Chain = chain
+
+	return policy
+}
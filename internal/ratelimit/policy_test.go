@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+func newPolicyLimiter(t *testing.T) (ClientRateLimiter, storage.Storage) {
+	t.Helper()
+
+	cfg := &config.RateLimitConfig{
+		Default: config.TokenBucketConfig{Capacity: 10, RefillRate: 1},
+		Groups: map[string]config.TokenBucketConfig{
+			"free": {Capacity: 20, RefillRate: 2},
+			"pro":  {Capacity: 100, RefillRate: 10},
+		},
+		CIDRs: []config.CIDRLimitConfig{
+			{CIDR: "10.0.0.0/8", Capacity: 30, RefillRate: 3},
+			{CIDR: "10.1.0.0/16", Capacity: 40, RefillRate: 4},
+		},
+	}
+	store := storage.NewMemoryStorage()
+	limiter, err := NewRateLimiter(store, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create limiter: %v", err)
+	}
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter, store
+}
+
+func TestClientLimits_ResolvePolicy(t *testing.T) {
+	limiter, _ := newPolicyLimiter(t)
+	ctx := context.Background()
+
+	if err := limiter.UpdateClientGroup(ctx, "key-pro", "pro"); err != nil {
+		t.Fatalf("UpdateClientGroup failed: %v", err)
+	}
+	if err := limiter.UpdateClientGroup(ctx, "10.1.2.3", "free"); err != nil {
+		t.Fatalf("UpdateClientGroup failed: %v", err)
+	}
+	if err := limiter.UpdateClientConfig(ctx, "10.1.2.3", 500, 50); err != nil {
+		t.Fatalf("UpdateClientConfig failed: %v", err)
+	}
+	if err := limiter.UpdateClientGroup(ctx, "key-x", "enterprise"); err != ErrUnknownGroup {
+		t.Errorf("Expected ErrUnknownGroup, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		clientID string
+		ip       string
+		level    string
+		policy   string
+		capacity int
+	}{
+		{"override beats group", "10.1.2.3", "", PolicyOverride, "", 500},
+		{"group beats cidr", "key-pro", "10.1.2.3", PolicyGroup, "pro", 100},
+		{"longest prefix", "key-other", "10.1.9.9", PolicyCIDR, "10.1.0.0/16", 40},
+		{"shorter prefix", "10.2.0.1", "", PolicyCIDR, "10.0.0.0/8", 30},
+		{"ip identity", "ip:10.2.0.1", "", PolicyCIDR, "10.0.0.0/8", 30},
+		{"key identity is not an address", "header:X-API-Key:10.2.0.1", "", PolicyDefault, "", 10},
+		{"default", "192.168.0.1", "", PolicyDefault, "", 10},
+	}
+
+	for _, tt := range tests {
+		policy, err := limiter.ResolvePolicy(ctx, tt.clientID, tt.ip)
+		if err != nil {
+			t.Fatalf("%s: ResolvePolicy failed: %v", tt.name, err)
+		}
+		if policy.Level != tt.level || policy.Name != tt.policy || policy.Capacity != tt.capacity {
+			t.Errorf("%s: got %s %q capacity %d, want %s %q capacity %d",
+				tt.name, policy.Level, policy.Name, policy.Capacity, tt.level, tt.policy, tt.capacity)
+		}
+		if len(policy.Chain) != 4 {
+			t.Errorf("%s: expected 4 steps in chain, got %d", tt.name, len(policy.Chain))
+		}
+	}
+
+	if err := limiter.DeleteClientConfig(ctx, "10.1.2.3"); err != nil {
+		t.Fatalf("DeleteClientConfig failed: %v", err)
+	}
+	if policy, _ := limiter.ResolvePolicy(ctx, "10.1.2.3", ""); policy.Level != PolicyCIDR {
+		t.Errorf("Expected cidr policy after delete, got %s", policy.Level)
+	}
+}
+
+func TestClientManager_GroupStatus(t *testing.T) {
+	limiter, store := newPolicyLimiter(t)
+	concurrency := NewConcurrencyLimiter(store, &config.RateLimitConfig{})
+	cm := NewClientManager(store, limiter, concurrency, nil)
+
+	rec := httptest.NewRecorder()
+	cm.HandleCRUD(rec, httptest.NewRequest(http.MethodPost, "/clients", strings.NewReader(`{"client_id":"key1","group":"enterprise"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Unknown group status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	cm.HandleCRUD(rec, httptest.NewRequest(http.MethodPost, "/clients", strings.NewReader(`{"client_id":"key1","group":"pro"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Add client status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var added ClientConfigResponse
+	if err := json.NewDecoder(rec.Body).Decode(&added); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if added.Policy != PolicyGroup || added.Capacity != 100 {
+		t.Errorf("Added client policy = %s capacity %d, want group capacity 100", added.Policy, added.Capacity)
+	}
+
+	rec = httptest.NewRecorder()
+	cm.HandleStatus(rec, httptest.NewRequest(http.MethodGet, "/client-status?client_id=key1", nil))
+
+	var status struct {
+		Policy struct {
+			Level string       `json:"level"`
+			Name  string       `json:"name"`
+			Chain []PolicyStep `json:"chain"`
+		} `json:"policy"`
+		Capacity int `json:"capacity"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.Policy.Level != PolicyGroup || status.Policy.Name != "pro" || status.Capacity != 100 {
+		t.Errorf("Status policy = %+v, capacity %d", status.Policy, status.Capacity)
+	}
+	if len(status.Policy.Chain) != 4 || !status.Policy.Chain[1].Applied || status.Policy.Chain[0].Matched {
+		t.Errorf("Unexpected resolution chain: %+v", status.Policy.Chain)
+	}
+}
+
+func TestClientManager_StatusIP(t *testing.T) {
+	limiter, store := newPolicyLimiter(t)
+	concurrency := NewConcurrencyLimiter(store, &config.RateLimitConfig{})
+	cm := NewClientManager(store, limiter, concurrency, nil)
+
+	status := func(target string) (int, string, int) {
+		rec := httptest.NewRecorder()
+		cm.HandleStatus(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var resp struct {
+			IP     string `json:"ip"`
+			Policy struct {
+				Level string `json:"level"`
+			} `json:"policy"`
+			Capacity int `json:"capacity"`
+		}
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode status: %v", err)
+			}
+		}
+		return rec.Code, resp.Policy.Level + " " + resp.IP, resp.Capacity
+	}
+
+	// Клиент, определённый ключом: без ip диапазоны к нему не применяются.
+	if code, policy, capacity := status("/client-status?client_id=key1"); code != http.StatusOK || policy != PolicyDefault+" " || capacity != 10 {
+		t.Errorf("Status without ip = %d %q capacity %d, want default", code, policy, capacity)
+	}
+
+	if code, policy, capacity := status("/client-status?client_id=key1&ip=10.1.2.3"); code != http.StatusOK || policy != PolicyCIDR+" 10.1.2.3" || capacity != 40 {
+		t.Errorf("Status with ip = %d %q capacity %d, want cidr capacity 40", code, policy, capacity)
+	}
+
+	if code, _, _ := status("/client-status?client_id=key1&ip=not-an-ip"); code != http.StatusBadRequest {
+		t.Errorf("Status with invalid ip = %d, want 400", code)
+	}
+
+	// Без client_id клиент и его IP берутся из запроса, как в прокси.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/client-status", nil)
+	req.RemoteAddr = "10.9.9.9:1234"
+	cm.HandleStatus(rec, req)
+	if !strings.Contains(rec.Body.String(), `"level":"cidr"`) || !strings.Contains(rec.Body.String(), `"capacity":30`) {
+		t.Errorf("Status for caller = %s, want cidr capacity 30", rec.Body)
+	}
+
+	// Адрес из X-Forwarded-For без доверенного прокси не меняет уровень.
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/client-status", nil)
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	cm.HandleStatus(rec, req)
+	if !strings.Contains(rec.Body.String(), `"level":"default"`) || !strings.Contains(rec.Body.String(), `"ip":"192.0.2.1"`) {
+		t.Errorf("Status with spoofed X-Forwarded-For = %s, want default for connection address", rec.Body)
+	}
+}
@@ -0,0 +1,303 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/identity"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
+)
+
+var log = logger.For(logger.RateLimit)
+
+var (
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+)
+
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+	AlgorithmGCRA          = "gcra"
+)
+
+// Result - решение лимитера по запросу.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter - через сколько запрос той же стоимости будет разрешён.
+	// Ноль, если запрос разрешён или алгоритм не умеет это оценить.
+	RetryAfter time.Duration
+	// Reset - через сколько лимит восстановится полностью.
+	Reset time.Duration
+	// Window - за какое время восстанавливается весь лимит, для заголовка
+	// RateLimit-Policy.
+	Window time.Duration
+	// Layer - уровень, к которому относятся Limit и Remaining: отклонивший
+	// запрос или, если запрос разрешён, с наименьшим остатком.
+	Layer string
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, clientID string, tokens int) (Result, error)
+
+	// AllowRequest проверяет запрос на всех уровнях лимитов: глобальном,
+	// маршрута и клиента.
+	AllowRequest(ctx context.Context, req Request) (Result, error)
+
+	Close() error
+}
+
+// ClientRateLimiter - лимитер с настройками по клиентам, которыми управляют
+// API /clients и перезагрузка конфигурации.
+type ClientRateLimiter interface {
+	RateLimiter
+
+	DefaultConfig() config.TokenBucketConfig
+	UpdateDefaultConfig(cfg config.TokenBucketConfig)
+	UpdateClientConfig(ctx context.Context, clientID string, capacity, refillRate int) error
+
+	// HasGroup сообщает, описана ли группа в конфигурации.
+	HasGroup(name string) bool
+
+	// UpdateClientGroup назначает клиенту группу, пустая строка убирает
+	// клиента из группы.
+	UpdateClientGroup(ctx context.Context, clientID, group string) error
+
+	// DeleteClientConfig удаляет собственный лимит и группу клиента.
+	DeleteClientConfig(ctx context.Context, clientID string) error
+
+	// ResolvePolicy возвращает действующий лимит клиента с адресом ip. Если
+	// ip пуст, адресом считается clientID.
+	ResolvePolicy(ctx context.Context, clientID, ip string) (Policy, error)
+}
+
+// NewRateLimiter создаёт лимитер по rate_limit.algorithm.
+func NewRateLimiter(store storage.Storage, cfg *config.RateLimitConfig) (ClientRateLimiter, error) {
+	var (
+		limiter ClientRateLimiter
+		err     error
+	)
+
+	if cfg.Algorithm != "" && cfg.Algorithm != AlgorithmTokenBucket && !newLayers(cfg).empty() {
+		return nil, fmt.Errorf("global and route rate limits require %s algorithm", AlgorithmTokenBucket)
+	}
+
+	switch cfg.Algorithm {
+	case "", AlgorithmTokenBucket:
+		limiter, err = NewTokenBucketRateLimiter(store, cfg)
+	case AlgorithmSlidingWindow:
+		limiter, err = NewSlidingWindowRateLimiter(store, cfg)
+	case AlgorithmGCRA:
+		limiter, err = NewGCRARateLimiter(store, cfg)
+	default:
+		return nil, fmt.Errorf("unknown rate limit algorithm: %s", cfg.Algorithm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// clientLimits - настройки клиентов, общие для всех алгоритмов: значения по
+// умолчанию, лимиты групп и диапазонов адресов и кэш настроек из хранилища.
+type clientLimits struct {
+	storage       storage.Storage
+	policies      policies
+	defaultMu     sync.RWMutex
+	defaultConfig config.TokenBucketConfig
+	clientsMu     sync.RWMutex
+	clients       map[string]*clientConfig
+}
+
+// clientConfig - настройки клиента из хранилища: собственный лимит или, если
+// он не задан (inherited), лимит по умолчанию, и группа клиента.
+type clientConfig struct {
+	capacity   int
+	refillRate int
+	inherited  bool
+	group      string
+}
+
+func newClientLimits(store storage.Storage, cfg *config.RateLimitConfig) clientLimits {
+	return clientLimits{
+		storage:       store,
+		policies:      newPolicies(cfg),
+		defaultConfig: cfg.Default,
+		clients:       make(map[string]*clientConfig),
+	}
+}
+
+func (cl *clientLimits) getClientConfig(ctx context.Context, clientID, ip string) (capacity int, refillRate int, err error) {
+	policy, err := cl.ResolvePolicy(ctx, clientID, ip)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return policy.Capacity, policy.RefillRate, nil
+}
+
+func (cl *clientLimits) ResolvePolicy(ctx context.Context, clientID, ip string) (Policy, error) {
+	stored, err := cl.storedConfig(ctx, clientID)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	// Без ip адресом считается сам клиент, определённый по IP.
+	if ip == "" {
+		ip = clientID
+		if addr, ok := identity.IP(clientID); ok {
+			ip = addr
+		}
+	}
+
+	return cl.policies.resolve(stored, ip, cl.DefaultConfig()), nil
+}
+
+func (cl *clientLimits) storedConfig(ctx context.Context, clientID string) (clientConfig, error) {
+	cl.clientsMu.RLock()
+	if config, ok := cl.clients[clientID]; ok {
+		cl.clientsMu.RUnlock()
+		return *config, nil
+	}
+	cl.clientsMu.RUnlock()
+
+	capacity, refillRate, err := cl.storage.GetClientConfig(ctx, clientID)
+	if err != nil {
+		return clientConfig{}, err
+	}
+
+	group, err := cl.storage.GetClientGroup(ctx, clientID)
+	if err != nil {
+		return clientConfig{}, err
+	}
+
+	config := clientConfig{
+		capacity:   capacity,
+		refillRate: refillRate,
+		group:      group,
+	}
+	if capacity == 0 || refillRate == 0 {
+		defaults := cl.DefaultConfig()
+		config.capacity = defaults.Capacity
+		config.refillRate = defaults.RefillRate
+		config.inherited = true
+	}
+
+	cl.clientsMu.Lock()
+	cl.clients[clientID] = &config
+	cl.clientsMu.Unlock()
+
+	return config, nil
+}
+
+func (cl *clientLimits) UpdateClientConfig(ctx context.Context, clientID string, capacity, refillRate int) error {
+
+	if capacity <= 0 || refillRate <= 0 {
+		return errors.New("capacity and refillRate must be positive")
+	}
+
+	if err := cl.storage.SetClientConfig(ctx, clientID, capacity, refillRate); err != nil {
+		return err
+	}
+
+	group, err := cl.storage.GetClientGroup(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	cl.clientsMu.Lock()
+	cl.clients[clientID] = &clientConfig{
+		capacity:   capacity,
+		refillRate: refillRate,
+		group:      group,
+	}
+	cl.clientsMu.Unlock()
+
+	log.Info().
+		Str("client_id", clientID).
+		Int("capacity", capacity).
+		Int("refill_rate", refillRate).
+		Msg("Client rate limit config updated")
+
+	return nil
+}
+
+func (cl *clientLimits) HasGroup(name string) bool {
+	_, ok := cl.policies.group(name)
+	return ok
+}
+
+func (cl *clientLimits) UpdateClientGroup(ctx context.Context, clientID, group string) error {
+	if group != "" {
+		if _, ok := cl.policies.group(group); !ok {
+			return ErrUnknownGroup
+		}
+	}
+
+	if err := cl.storage.SetClientGroup(ctx, clientID, group); err != nil {
+		return err
+	}
+	cl.forget(clientID)
+
+	log.Info().Str("client_id", clientID).Str("group", group).Msg("Client rate limit group updated")
+
+	return nil
+}
+
+func (cl *clientLimits) DeleteClientConfig(ctx context.Context, clientID string) error {
+	if err := cl.storage.SetClientConfig(ctx, clientID, 0, 0); err != nil {
+		return err
+	}
+	if err := cl.storage.SetClientGroup(ctx, clientID, ""); err != nil {
+		return err
+	}
+	cl.forget(clientID)
+
+	return nil
+}
+
+// forget убирает клиента из кэша, чтобы настройки перечитались из хранилища.
+func (cl *clientLimits) forget(clientID string) {
+	cl.clientsMu.Lock()
+	delete(cl.clients, clientID)
+	cl.clientsMu.Unlock()
+}
+
+func (cl *clientLimits) DefaultConfig() config.TokenBucketConfig {
+	cl.defaultMu.RLock()
+	defer cl.defaultMu.RUnlock()
+
+	return cl.defaultConfig
+}
+
+func (cl *clientLimits) UpdateDefaultConfig(cfg config.TokenBucketConfig) {
+	cl.defaultMu.Lock()
+	cl.defaultConfig = cfg
+	cl.defaultMu.Unlock()
+
+	// В кэше могли остаться значения по умолчанию, поэтому он сбрасывается целиком.
+	cl.clientsMu.Lock()
+	cl.clients = make(map[string]*clientConfig)
+	cl.clientsMu.Unlock()
+
+	log.Info().
+		Int("capacity", cfg.Capacity).
+		Int("refill_rate", cfg.RefillRate).
+		Msg("Default rate limit config updated")
+}
+
+// refillWindow - время, за которое бакет ёмкостью capacity пополняется
+// полностью.
+func refillWindow(capacity, refillRate int) time.Duration {
+	if refillRate <= 0 {
+		return 0
+	}
+	return time.Duration(capacity) * time.Second / time.Duration(refillRate)
+}
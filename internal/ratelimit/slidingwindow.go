@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+// SlidingWindowRateLimiter пропускает не больше capacity запросов за
+// скользящее окно rate_limit.window. Окно приближается счётчиками двух
+// фиксированных окон, refill_rate клиента не используется.
+type SlidingWindowRateLimiter struct {
+	clientLimits
+	window time.Duration
+}
+
+func NewSlidingWindowRateLimiter(store storage.Storage, cfg *config.RateLimitConfig) (*SlidingWindowRateLimiter, error) {
+	limiter := &SlidingWindowRateLimiter{
+		clientLimits: newClientLimits(store, cfg),
+		window:       cfg.Window,
+	}
+
+	if limiter.window <= 0 {
+		limiter.window = time.Minute
+	}
+
+	if err := store.Ping(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// AllowRequest проверяет только лимит клиента: общие лимиты поддерживаются
+// лишь алгоритмом token_bucket.
+func (sw *SlidingWindowRateLimiter) AllowRequest(ctx context.Context, req Request) (Result, error) {
+	return sw.allow(ctx, req.ClientID, req.IP, req.Tokens)
+}
+
+func (sw *SlidingWindowRateLimiter) Allow(ctx context.Context, clientID string, tokens int) (Result, error) {
+	return sw.allow(ctx, clientID, "", tokens)
+}
+
+func (sw *SlidingWindowRateLimiter) allow(ctx context.Context, clientID, ip string, tokens int) (Result, error) {
+	if tokens <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	limit, _, err := sw.getClientConfig(ctx, clientID, ip)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
+		return Result{}, err
+	}
+
+	window, err := sw.storage.SlidingWindow(ctx, clientID, tokens, limit, sw.window)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("client_id", clientID).Msg("Failed to update sliding window")
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:    window.Allowed,
+		Limit:      limit,
+		Remaining:  window.Remaining,
+		RetryAfter: window.RetryAfter,
+		Reset:      window.Reset,
+		Window:     sw.window,
+	}
+
+	if !result.Allowed {
+		log.Ctx(ctx).Debug().
+			Str("client_id", clientID).
+			Int("requested", tokens).
+			Int("remaining", result.Remaining).
+			Int("limit", limit).
+			Dur("retry_after", result.RetryAfter).
+			Msg("Rate limit exceeded")
+		return result, ErrRateLimitExceeded
+	}
+
+	log.Ctx(ctx).Debug().
+		Str("client_id", clientID).
+		Int("requested", tokens).
+		Int("remaining", result.Remaining).
+		Int("limit", limit).
+		Msg("Request allowed")
+
+	return result, nil
+}
+
+func (sw *SlidingWindowRateLimiter) Close() error {
+	return nil
+}
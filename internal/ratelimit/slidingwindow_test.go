@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+func TestSlidingWindowRateLimiter_Allow(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Algorithm: AlgorithmSlidingWindow,
+		Window:    time.Hour,
+		Default: config.TokenBucketConfig{
+			Capacity:   3,
+			RefillRate: 1,
+		},
+	}
+
+	limiter, err := NewRateLimiter(storage.NewMemoryStorage(), cfg)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	if _, ok := limiter.(*SlidingWindowRateLimiter); !ok {
+		t.Fatalf("Expected sliding window limiter, got %T", limiter)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "client1", 1)
+		if err != nil || !result.Allowed {
+			t.Fatalf("Request %d should be allowed: %+v, %v", i, result, err)
+		}
+		if result.Limit != 3 || result.Remaining != 2-i {
+			t.Errorf("Unexpected result for request %d: %+v", i, result)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, "client1", 1)
+	if !errors.Is(err, ErrRateLimitExceeded) || result.Allowed {
+		t.Fatalf("Expected request over the limit to be denied, got %+v, %v", result, err)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > 2*time.Hour {
+		t.Errorf("Unexpected retry after: %s", result.RetryAfter)
+	}
+
+	if result, err := limiter.Allow(ctx, "client2", 1); err != nil || !result.Allowed {
+		t.Errorf("Expected other client to be allowed, got %+v, %v", result, err)
+	}
+
+	if err := limiter.UpdateClientConfig(ctx, "client1", 5, 1); err != nil {
+		t.Fatalf("UpdateClientConfig failed: %v", err)
+	}
+	if result, err := limiter.Allow(ctx, "client1", 1); err != nil || !result.Allowed || result.Limit != 5 {
+		t.Errorf("Expected raised limit to allow request, got %+v, %v", result, err)
+	}
+}
+
+func TestNewRateLimiter_UnknownAlgorithm(t *testing.T) {
+	cfg := &config.RateLimitConfig{Algorithm: "leaky_bucket"}
+
+	if _, err := NewRateLimiter(storage.NewMemoryStorage(), cfg); err == nil {
+		t.Error("Expected error for unknown algorithm")
+	}
+}
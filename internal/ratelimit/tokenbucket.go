@@ -2,46 +2,24 @@ package ratelimit
 
 import (
 	"context"
-	"errors"
-	"sync"
 	"time"
 
 	"go-cloud-camp-2025-test-assignment/config"
 	"go-cloud-camp-2025-test-assignment/internal/storage"
-
-	"github.com/rs/zerolog/log"
-)
-
-var (
-	ErrRateLimitExceeded = errors.New("rate limit exceeded")
 )
 
-type RateLimiter interface {
-	Allow(ctx context.Context, clientID string, tokens int) (bool, int, error)
-
-	Close() error
-}
-
 type TokenBucketRateLimiter struct {
-	storage       storage.Storage
-	defaultConfig config.TokenBucketConfig
-	ticker        *time.Ticker
-	stop          chan struct{}
-	clientsMu     sync.RWMutex
-	clients       map[string]*clientConfig
-}
-
-type clientConfig struct {
-	capacity   int
-	refillRate int
+	clientLimits
+	layers layers
+	ticker *time.Ticker
+	stop   chan struct{}
 }
 
 func NewTokenBucketRateLimiter(store storage.Storage, cfg *config.RateLimitConfig) (*TokenBucketRateLimiter, error) {
 	limiter := &TokenBucketRateLimiter{
-		storage:       store,
-		defaultConfig: cfg.Default,
-		clients:       make(map[string]*clientConfig),
-		stop:          make(chan struct{}),
+		clientLimits: newClientLimits(store, cfg),
+		layers:       newLayers(cfg),
+		stop:         make(chan struct{}),
 	}
 
 	if err := store.Ping(context.Background()); err != nil {
@@ -51,97 +29,114 @@ func NewTokenBucketRateLimiter(store storage.Storage, cfg *config.RateLimitConfi
 	return limiter, nil
 }
 
-func (tb *TokenBucketRateLimiter) Allow(ctx context.Context, clientID string, tokens int) (bool, int, error) {
-	if tokens <= 0 {
+func (tb *TokenBucketRateLimiter) Allow(ctx context.Context, clientID string, tokens int) (Result, error) {
+	return tb.AllowRequest(ctx, Request{ClientID: clientID, Tokens: tokens})
+}
 
-		return true, 0, nil
+// AllowRequest списывает токены сразу со всех подходящих бакетов: глобального,
+// маршрута и клиента. Если хотя бы один отклоняет запрос, токены не
+// списываются ни с одного.
+func (tb *TokenBucketRateLimiter) AllowRequest(ctx context.Context, req Request) (Result, error) {
+	if req.Tokens <= 0 {
+		return Result{Allowed: true}, nil
 	}
 
-	capacity, refillRate, err := tb.getClientConfig(ctx, clientID)
+	capacity, refillRate, err := tb.getClientConfig(ctx, req.ClientID, req.IP)
 	if err != nil {
-		log.Error().Err(err).Str("client_id", clientID).Msg("Failed to get client config")
-		return false, 0, err
+		log.Ctx(ctx).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to get client config")
+		return Result{}, err
 	}
 
-	allowed, remaining, err := tb.storage.TakeTokens(ctx, clientID, tokens, capacity, refillRate)
-	if err != nil {
-		log.Error().Err(err).Str("client_id", clientID).Msg("Failed to take tokens")
-		return false, 0, err
+	shared := tb.layers.match(req.Method, req.Path)
+
+	var result Result
+	if len(shared) == 0 {
+		bucket, err := tb.storage.TakeTokens(ctx, req.ClientID, req.Tokens, capacity, refillRate)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to take tokens")
+			return Result{}, err
+		}
+		result = bucketResult(bucket, LayerClient, capacity, refillRate)
+	} else {
+		result, err = tb.takeLayered(ctx, req, shared, capacity, refillRate)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("client_id", req.ClientID).Msg("Failed to take tokens")
+			return Result{}, err
+		}
 	}
 
-	if !allowed {
-		log.Debug().
-			Str("client_id", clientID).
-			Int("requested", tokens).
-			Int("remaining", remaining).
-			Int("capacity", capacity).
+	if !result.Allowed {
+		log.Ctx(ctx).Debug().
+			Str("client_id", req.ClientID).
+			Str("layer", result.Layer).
+			Int("requested", req.Tokens).
+			Int("remaining", result.Remaining).
+			Int("capacity", result.Limit).
+			Dur("retry_after", result.RetryAfter).
 			Msg("Rate limit exceeded")
-		return false, remaining, ErrRateLimitExceeded
+		return result, ErrRateLimitExceeded
 	}
 
-	log.Debug().
-		Str("client_id", clientID).
-		Int("requested", tokens).
-		Int("remaining", remaining).
-		Int("capacity", capacity).
+	log.Ctx(ctx).Debug().
+		Str("client_id", req.ClientID).
+		Str("layer", result.Layer).
+		Int("requested", req.Tokens).
+		Int("remaining", result.Remaining).
+		Int("capacity", result.Limit).
 		Msg("Request allowed")
 
-	return true, remaining, nil
+	return result, nil
 }
 
-func (tb *TokenBucketRateLimiter) getClientConfig(ctx context.Context, clientID string) (capacity int, refillRate int, err error) {
-
-	tb.clientsMu.RLock()
-	if config, ok := tb.clients[clientID]; ok {
-		tb.clientsMu.RUnlock()
-		return config.capacity, config.refillRate, nil
+// takeLayered атомарно списывает токены с общих бакетов и бакета клиента.
+func (tb *TokenBucketRateLimiter) takeLayered(ctx context.Context, req Request, shared []layer, capacity, refillRate int) (Result, error) {
+	names := make([]string, 0, len(shared)+1)
+	buckets := make([]storage.Bucket, 0, len(shared)+1)
+	for _, l := range shared {
+		names = append(names, l.name)
+		buckets = append(buckets, storage.Bucket{
+			Key:        l.key,
+			Tokens:     req.Tokens,
+			Capacity:   l.capacity,
+			RefillRate: l.refillRate,
+		})
 	}
-	tb.clientsMu.RUnlock()
-
-	capacity, refillRate, err = tb.storage.GetClientConfig(ctx, clientID)
+	names = append(names, LayerClient)
+	buckets = append(buckets, storage.Bucket{
+		Key:        storage.ClientKey(req.ClientID),
+		Tokens:     req.Tokens,
+		Capacity:   capacity,
+		RefillRate: refillRate,
+	})
+
+	rejected, results, err := tb.storage.TakeTokensMulti(ctx, buckets)
 	if err != nil {
-		return 0, 0, err
-	}
-
-	if capacity == 0 || refillRate == 0 {
-		capacity = tb.defaultConfig.Capacity
-		refillRate = tb.defaultConfig.RefillRate
+		return Result{}, err
 	}
 
-	tb.clientsMu.Lock()
-	tb.clients[clientID] = &clientConfig{
-		capacity:   capacity,
-		refillRate: refillRate,
+	idx := rejected
+	if idx < 0 {
+		idx = 0
+		for i := range results {
+			if results[i].Remaining < results[idx].Remaining {
+				idx = i
+			}
+		}
 	}
-	tb.clientsMu.Unlock()
 
-	return capacity, refillRate, nil
+	return bucketResult(results[idx], names[idx], buckets[idx].Capacity, buckets[idx].RefillRate), nil
 }
 
-func (tb *TokenBucketRateLimiter) UpdateClientConfig(ctx context.Context, clientID string, capacity, refillRate int) error {
-
-	if capacity <= 0 || refillRate <= 0 {
-		return errors.New("capacity and refillRate must be positive")
-	}
-
-	if err := tb.storage.SetClientConfig(ctx, clientID, capacity, refillRate); err != nil {
-		return err
+func bucketResult(bucket storage.LimitResult, layer string, capacity, refillRate int) Result {
+	return Result{
+		Allowed:    bucket.Allowed,
+		Limit:      capacity,
+		Remaining:  bucket.Remaining,
+		RetryAfter: bucket.RetryAfter,
+		Reset:      bucket.Reset,
+		Window:     refillWindow(capacity, refillRate),
+		Layer:      layer,
 	}
-
-	tb.clientsMu.Lock()
-	tb.clients[clientID] = &clientConfig{
-		capacity:   capacity,
-		refillRate: refillRate,
-	}
-	tb.clientsMu.Unlock()
-
-	log.Info().
-		Str("client_id", clientID).
-		Int("capacity", capacity).
-		Int("refill_rate", refillRate).
-		Msg("Client rate limit config updated")
-
-	return nil
 }
 
 func (tb *TokenBucketRateLimiter) Close() error {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
 	"testing"
 	"time"
 )
@@ -23,9 +24,17 @@ func NewMockStorage() *MockStorage {
 	}
 }
 
-func (m *MockStorage) TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (bool, int, error) {
+func (m *MockStorage) TakeTokensMulti(ctx context.Context, buckets []storage.Bucket) (int, []storage.LimitResult, error) {
+	results := make([]storage.LimitResult, len(buckets))
+	for i := range results {
+		results[i].Allowed = true
+	}
+	return -1, results, nil
+}
+
+func (m *MockStorage) TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (storage.LimitResult, error) {
 	if m.takeTokensErr != nil {
-		return false, 0, m.takeTokensErr
+		return storage.LimitResult{}, m.takeTokensErr
 	}
 
 	if _, ok := m.tokens[key]; !ok {
@@ -34,10 +43,59 @@ func (m *MockStorage) TakeTokens(ctx context.Context, key string, tokensToTake i
 
 	if m.tokens[key] >= tokensToTake {
 		m.tokens[key] -= tokensToTake
-		return true, m.tokens[key], nil
+		return storage.LimitResult{Allowed: true, Remaining: m.tokens[key]}, nil
+	}
+
+	return storage.LimitResult{Remaining: m.tokens[key]}, nil
+}
+
+func (m *MockStorage) SlidingWindow(ctx context.Context, key string, cost int, limit int, window time.Duration) (storage.LimitResult, error) {
+	if m.takeTokensErr != nil {
+		return storage.LimitResult{}, m.takeTokensErr
 	}
 
-	return false, m.tokens[key], nil
+	if _, ok := m.tokens[key]; !ok {
+		m.tokens[key] = limit
+	}
+
+	if m.tokens[key] >= cost {
+		m.tokens[key] -= cost
+		return storage.LimitResult{Allowed: true, Remaining: m.tokens[key]}, nil
+	}
+
+	return storage.LimitResult{Remaining: m.tokens[key], RetryAfter: window}, nil
+}
+
+func (m *MockStorage) GCRA(ctx context.Context, key string, cost int, capacity int, refillRate int) (storage.LimitResult, error) {
+	return m.TakeTokens(ctx, key, cost, capacity, refillRate)
+}
+
+func (m *MockStorage) GetClientGroup(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (m *MockStorage) SetClientGroup(ctx context.Context, key string, group string) error {
+	return nil
+}
+
+func (m *MockStorage) AcquireLease(ctx context.Context, key string, leaseID string, limit int, ttl time.Duration) (bool, int, error) {
+	return true, 1, nil
+}
+
+func (m *MockStorage) ReleaseLease(ctx context.Context, key string, leaseID string) error {
+	return nil
+}
+
+func (m *MockStorage) InFlight(ctx context.Context, key string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockStorage) GetClientMaxInFlight(ctx context.Context, key string) (int, error) {
+	return 0, m.configErr
+}
+
+func (m *MockStorage) SetClientMaxInFlight(ctx context.Context, key string, maxInFlight int) error {
+	return m.configErr
 }
 
 func (m *MockStorage) GetClientConfig(ctx context.Context, key string) (capacity int, refillRate int, err error) {
@@ -185,7 +243,8 @@ func TestTokenBucketRateLimiter_Allow(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			allowed, remaining, err := limiter.Allow(ctx, tt.clientID, tt.tokens)
+			result, err := limiter.Allow(ctx, tt.clientID, tt.tokens)
+			allowed, remaining := result.Allowed, result.Remaining
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Allow() error = %v, wantErr %v", err, tt.wantErr)
@@ -310,7 +369,7 @@ func TestTokenBucketRateLimiter_getClientConfig(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			capacity, refillRate, err := limiter.getClientConfig(ctx, tt.clientID)
+			capacity, refillRate, err := limiter.getClientConfig(ctx, tt.clientID, "")
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getClientConfig() error = %v, wantErr %v", err, tt.wantErr)
@@ -0,0 +1,218 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/discovery"
+	"go-cloud-camp-2025-test-assignment/internal/health"
+	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type Reloader struct {
+	configPath  string
+	mu          sync.Mutex
+	current     *config.Config
+	backends    *discovery.Manager
+	monitor     *health.Monitor
+	rateLimiter ratelimit.ClientRateLimiter
+	concurrency *ratelimit.ConcurrencyLimiter
+}
+
+func NewReloader(configPath string, cfg *config.Config, backends *discovery.Manager, monitor *health.Monitor, limiter ratelimit.ClientRateLimiter, concurrency *ratelimit.ConcurrencyLimiter) *Reloader {
+	return &Reloader{
+		configPath:  configPath,
+		current:     cfg,
+		backends:    backends,
+		monitor:     monitor,
+		rateLimiter: limiter,
+		concurrency: concurrency,
+	}
+}
+
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.LoadConfig(r.configPath)
+	if err != nil {
+		log.Error().Err(err).Str("path", r.configPath).Msg("Config reload rejected, keeping running config")
+		return err
+	}
+
+	prev := r.current
+
+	added, removed := r.backends.SetStatic(next.Backends)
+
+	if !reflect.DeepEqual(prev.Balancer, next.Balancer) {
+		r.backends.SwapBalancer(next.Balancer.Algorithm, balancer.Options(next)...)
+		log.Info().
+			Str("from", prev.Balancer.Algorithm).
+			Str("to", next.Balancer.Algorithm).
+			Msg("Balancer replaced")
+	}
+
+	if prev.HealthCheck != next.HealthCheck {
+		r.monitor.Start(next.HealthCheck)
+		log.Info().
+			Bool("enabled", next.HealthCheck.Enabled).
+			Dur("interval", next.HealthCheck.Interval).
+			Msg("Health checks restarted")
+	}
+
+	if r.rateLimiter != nil && prev.RateLimit.Default != next.RateLimit.Default {
+		r.rateLimiter.UpdateDefaultConfig(next.RateLimit.Default)
+	}
+
+	if r.concurrency != nil && prev.RateLimit.Concurrency != next.RateLimit.Concurrency {
+		r.concurrency.UpdateDefaultConfig(next.RateLimit.Concurrency)
+	}
+
+	if !strings.EqualFold(prev.Logging.Level, next.Logging.Level) {
+		if level, err := zerolog.ParseLevel(strings.ToLower(next.Logging.Level)); err == nil {
+			logger.SetLevel(level, 0)
+		}
+	}
+
+	warnRestartRequired(prev, next)
+
+	r.current = next
+
+	log.Info().
+		Int("backends_added", len(added)).
+		Int("backends_removed", len(removed)).
+		Msg("Configuration reloaded")
+
+	return nil
+}
+
+func warnRestartRequired(prev, next *config.Config) {
+	var changed []string
+
+	if prev.Server != next.Server {
+		changed = append(changed, "server")
+	}
+	if prev.Logging.Format != next.Logging.Format || prev.Logging.Output != next.Logging.Output || prev.Logging.FilePath != next.Logging.FilePath ||
+		prev.Logging.Rotation != next.Logging.Rotation {
+		changed = append(changed, "logging")
+	}
+	if prev.RateLimit.Enabled != next.RateLimit.Enabled || prev.RateLimit.Redis != next.RateLimit.Redis ||
+		prev.RateLimit.Algorithm != next.RateLimit.Algorithm || prev.RateLimit.Window != next.RateLimit.Window ||
+		prev.RateLimit.Global != next.RateLimit.Global || !reflect.DeepEqual(prev.RateLimit.Routes, next.RateLimit.Routes) ||
+		!reflect.DeepEqual(prev.RateLimit.Cost, next.RateLimit.Cost) || prev.RateLimit.Headers != next.RateLimit.Headers ||
+		!reflect.DeepEqual(prev.RateLimit.Identity, next.RateLimit.Identity) ||
+		!reflect.DeepEqual(prev.RateLimit.Groups, next.RateLimit.Groups) || !reflect.DeepEqual(prev.RateLimit.CIDRs, next.RateLimit.CIDRs) ||
+		!reflect.DeepEqual(prev.RateLimit.TrustedProxies, next.RateLimit.TrustedProxies) {
+		changed = append(changed, "rate_limit")
+	}
+	if prev.Admin != next.Admin {
+		changed = append(changed, "admin")
+	}
+	if prev.Reload != next.Reload {
+		changed = append(changed, "reload")
+	}
+	if !reflect.DeepEqual(prev.Discovery, next.Discovery) {
+		changed = append(changed, "discovery")
+	}
+	if prev.Sticky != next.Sticky {
+		changed = append(changed, "sticky_session")
+	}
+	if !reflect.DeepEqual(prev.Tracing, next.Tracing) {
+		changed = append(changed, "tracing")
+	}
+	if prev.RequestID != next.RequestID {
+		changed = append(changed, "request_id")
+	}
+	if !reflect.DeepEqual(prev.AccessLog, next.AccessLog) {
+		changed = append(changed, "access_log")
+	}
+
+	if len(changed) > 0 {
+		log.Warn().Strs("sections", changed).Msg("Some configuration changes require a restart to take effect")
+	}
+}
+
+// Watch перечитывает конфигурацию при изменении файла. Следит за каталогом,
+// а не за самим файлом, чтобы переживать атомарную замену файла редакторами.
+func (r *Reloader) Watch(ctx context.Context, debounce time.Duration) error {
+	if r.configPath == "" {
+		return errors.New("config file watching requires an explicit config path")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	path, err := filepath.Abs(r.configPath)
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.NewTimer(debounce)
+				fire = timer.C
+			case <-fire:
+				fire = nil
+				log.Info().Str("path", path).Msg("Config file changed, reloading")
+				r.Reload()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("Config watcher error")
+			}
+		}
+	}()
+
+	log.Info().Str("path", path).Dur("debounce", debounce).Msg("Watching config file for changes")
+
+	return nil
+}
@@ -0,0 +1,388 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+	"go-cloud-camp-2025-test-assignment/internal/admin"
+	"go-cloud-camp-2025-test-assignment/internal/balancer"
+	"go-cloud-camp-2025-test-assignment/internal/discovery"
+	"go-cloud-camp-2025-test-assignment/internal/health"
+	"go-cloud-camp-2025-test-assignment/internal/ratelimit"
+	"go-cloud-camp-2025-test-assignment/internal/storage"
+)
+
+type testConfig struct {
+	backends      []string
+	algorithm     string
+	healthEnabled bool
+	interval      string
+	healthPath    string
+	capacity      int
+}
+
+func (c testConfig) render() string {
+	var backends strings.Builder
+	for _, backend := range c.backends {
+		fmt.Fprintf(&backends, "  - url: %q\n", backend)
+	}
+
+	return fmt.Sprintf(`server:
+  port: 8080
+backends:
+%sbalancer:
+  algorithm: %s
+health_check:
+  enabled: %t
+  interval: %s
+  path: %s
+rate_limit:
+  enabled: true
+  default:
+    capacity: %d
+    refill_rate: 10
+`, backends.String(), c.algorithm, c.healthEnabled, c.interval, c.healthPath, c.capacity)
+}
+
+func defaultTestConfig() testConfig {
+	return testConfig{
+		backends:      []string{"http://backend1", "http://backend2"},
+		algorithm:     "round_robin",
+		healthEnabled: true,
+		interval:      "1h",
+		healthPath:    "/health",
+		capacity:      50,
+	}
+}
+
+type testReloader struct {
+	*Reloader
+	path    string
+	lb      *balancer.DynamicBalancer
+	limiter ratelimit.ClientRateLimiter
+}
+
+func writeConfig(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+}
+
+func newTestReloader(t *testing.T, initial testConfig) *testReloader {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, initial.render())
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	state, err := admin.NewStateStore("")
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+
+	initialBalancer, err := balancer.BalancerFactory(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create balancer: %v", err)
+	}
+	lb := balancer.NewDynamicBalancer(initialBalancer)
+
+	backends := discovery.NewManager(lb, state, cfg.Backends)
+	backends.Sync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	monitor := health.NewMonitor(ctx, lb)
+	monitor.Start(cfg.HealthCheck)
+	t.Cleanup(monitor.Stop)
+
+	store := storage.NewMemoryStorage()
+	t.Cleanup(func() { store.Close() })
+
+	limiter, err := ratelimit.NewRateLimiter(store, &cfg.RateLimit)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	t.Cleanup(func() { limiter.Close() })
+
+	concurrency := ratelimit.NewConcurrencyLimiter(store, &cfg.RateLimit)
+
+	return &testReloader{
+		Reloader: NewReloader(path, cfg, backends, monitor, limiter, concurrency),
+		path:     path,
+		lb:       lb,
+		limiter:  limiter,
+	}
+}
+
+func backendsByURL(lb balancer.Balancer) map[string]*balancer.Backend {
+	result := make(map[string]*balancer.Backend)
+	for _, backend := range lb.GetAllBackends() {
+		result[backend.URL.String()] = backend
+	}
+	return result
+}
+
+func TestReloader_InvalidConfigKeepsRunning(t *testing.T) {
+	r := newTestReloader(t, defaultTestConfig())
+
+	prev := r.Current()
+	prevBalancer := r.lb.Current()
+
+	invalid := defaultTestConfig()
+	invalid.backends = []string{"http://backend3"}
+	invalid.algorithm = "unknown"
+	writeConfig(t, r.path, invalid.render())
+
+	if err := r.Reload(); err == nil {
+		t.Fatal("Expected invalid config to be rejected")
+	}
+
+	if r.Current() != prev {
+		t.Error("Expected running config to be kept")
+	}
+	if r.lb.Current() != prevBalancer {
+		t.Error("Expected balancer to be kept")
+	}
+
+	backends := backendsByURL(r.lb)
+	if len(backends) != 2 || backends["http://backend1"] == nil || backends["http://backend2"] == nil {
+		t.Errorf("Expected original backends to be kept, got %v", backends)
+	}
+}
+
+func TestReloader_Backends(t *testing.T) {
+	r := newTestReloader(t, defaultTestConfig())
+
+	survivor := backendsByURL(r.lb)["http://backend1"]
+	survivor.RecordRequest(true)
+	survivor.RecordRequest(false)
+
+	next := defaultTestConfig()
+	next.backends = []string{"http://backend1", "http://backend3"}
+	writeConfig(t, r.path, next.render())
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	backends := backendsByURL(r.lb)
+	if len(backends) != 2 {
+		t.Fatalf("Expected 2 backends, got %d", len(backends))
+	}
+	if backends["http://backend2"] != nil {
+		t.Error("Expected backend2 to be removed")
+	}
+	if backends["http://backend3"] == nil {
+		t.Error("Expected backend3 to be added")
+	}
+
+	if backends["http://backend1"] != survivor {
+		t.Fatal("Expected backend1 to be kept as the same instance")
+	}
+	status := survivor.GetStatus()
+	if status.TotalRequests != 2 || status.FailedReqs != 1 {
+		t.Errorf("Expected backend1 stats to survive reload, got %d total and %d failed", status.TotalRequests, status.FailedReqs)
+	}
+}
+
+func TestReloader_AlgorithmChange(t *testing.T) {
+	r := newTestReloader(t, defaultTestConfig())
+
+	prevBalancer := r.lb.Current()
+	prevBackends := backendsByURL(r.lb)
+
+	next := defaultTestConfig()
+	next.algorithm = "least_connections"
+	writeConfig(t, r.path, next.render())
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if r.lb.Current() == prevBalancer {
+		t.Fatal("Expected balancer to be replaced")
+	}
+	if _, ok := r.lb.Current().(*balancer.LeastConnectionsBalancer); !ok {
+		t.Errorf("Expected least connections balancer, got %T", r.lb.Current())
+	}
+
+	backends := backendsByURL(r.lb)
+	for url, backend := range prevBackends {
+		if backends[url] != backend {
+			t.Errorf("Expected backend %s to move to the new balancer", url)
+		}
+	}
+}
+
+// healthServer считает проверки здоровья по путям.
+type healthServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	checks map[string]int
+}
+
+func newHealthServer(t *testing.T) *healthServer {
+	t.Helper()
+
+	hs := &healthServer{checks: make(map[string]int)}
+	hs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hs.mu.Lock()
+		hs.checks[r.URL.Path]++
+		hs.mu.Unlock()
+	}))
+	t.Cleanup(hs.Close)
+
+	return hs
+}
+
+func (hs *healthServer) count(path string) int {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	return hs.checks[path]
+}
+
+func (hs *healthServer) waitFor(t *testing.T, path string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hs.count(path) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected health checks on %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloader_HealthCheckRestart(t *testing.T) {
+	server := newHealthServer(t)
+
+	initial := defaultTestConfig()
+	initial.backends = []string{server.URL}
+	r := newTestReloader(t, initial)
+
+	next := initial
+	next.interval = "20ms"
+	writeConfig(t, r.path, next.render())
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	server.waitFor(t, "/health")
+
+	// Новый путь применяется без перезапуска процесса.
+	next.healthPath = "/ready"
+	writeConfig(t, r.path, next.render())
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	server.waitFor(t, "/ready")
+
+	// После отключения проверки прекращаются.
+	next.healthEnabled = false
+	writeConfig(t, r.path, next.render())
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	stopped := server.count("/ready")
+	time.Sleep(100 * time.Millisecond)
+	if got := server.count("/ready"); got != stopped {
+		t.Errorf("Expected health checks to stop, got %d more", got-stopped)
+	}
+}
+
+func TestReloader_InvalidBackendURL(t *testing.T) {
+	for _, backend := range []string{"http://[::1", "backend3:8080", "ftp://backend3", "http://"} {
+		t.Run(backend, func(t *testing.T) {
+			r := newTestReloader(t, defaultTestConfig())
+			prev := r.Current()
+
+			invalid := defaultTestConfig()
+			invalid.backends = []string{"http://backend1", backend}
+			writeConfig(t, r.path, invalid.render())
+
+			if err := r.Reload(); err == nil {
+				t.Fatal("Expected config with invalid backend url to be rejected")
+			}
+			if r.Current() != prev {
+				t.Error("Expected running config to be kept")
+			}
+
+			backends := backendsByURL(r.lb)
+			if len(backends) != 2 || backends["http://backend2"] == nil {
+				t.Errorf("Expected original backends to be kept, got %v", backends)
+			}
+		})
+	}
+}
+
+func TestReloader_DefaultRateLimit(t *testing.T) {
+	r := newTestReloader(t, defaultTestConfig())
+
+	next := defaultTestConfig()
+	next.capacity = 80
+	writeConfig(t, r.path, next.render())
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := r.limiter.DefaultConfig().Capacity; got != 80 {
+		t.Errorf("Expected default capacity 80, got %d", got)
+	}
+	if got := r.Current().RateLimit.Default.Capacity; got != 80 {
+		t.Errorf("Expected running config capacity 80, got %d", got)
+	}
+}
+
+func TestReloader_WatchDebounce(t *testing.T) {
+	r := newTestReloader(t, defaultTestConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	debounce := 300 * time.Millisecond
+	if err := r.Watch(ctx, debounce); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	next := defaultTestConfig()
+	next.capacity = 60
+	writeConfig(t, r.path, next.render())
+
+	time.Sleep(debounce / 3)
+	if got := r.limiter.DefaultConfig().Capacity; got != 50 {
+		t.Fatalf("Expected reload to wait for debounce, got capacity %d", got)
+	}
+
+	next.capacity = 70
+	writeConfig(t, r.path, next.render())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.limiter.DefaultConfig().Capacity != 70 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected debounced reload, got capacity %d", r.limiter.DefaultConfig().Capacity)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,87 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+const maxLength = 128
+
+type contextKey struct{}
+
+// Middleware присваивает запросу идентификатор: берёт его из заголовка
+// cfg.Header или генерирует UUIDv7. Идентификатор передаётся бэкенду в том же
+// заголовке, возвращается в ответе и добавляется ко всем записям логгера из
+// контекста запроса (log.Ctx).
+func Middleware(cfg config.RequestIDConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(cfg.Header)
+		if !valid(id) {
+			id = New()
+			r.Header.Set(cfg.Header, id)
+		}
+
+		w.Header().Set(cfg.Header, id)
+
+		logger := log.Ctx(r.Context()).With().Str("request_id", id).Logger()
+		ctx := context.WithValue(logger.WithContext(r.Context()), contextKey{}, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New генерирует UUIDv7 (RFC 9562): 48 бит времени в миллисекундах и
+// случайная часть, так что идентификаторы сортируются по времени создания.
+func New() string {
+	var u [16]byte
+	rand.Read(u[:])
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], ts[2:])
+
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf[:])
+}
+
+// valid принимает идентификаторы разумной длины из видимых ASCII-символов,
+// чтобы клиент не мог подставить в логи произвольные данные.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,83 @@
+package requestid
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var uuidV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNew(t *testing.T) {
+	prev := New()
+	for i := 0; i < 100; i++ {
+		id := New()
+		if !uuidV7.MatchString(id) {
+			t.Fatalf("New() = %s, want UUIDv7", id)
+		}
+		if id == prev {
+			t.Fatalf("New() returned duplicate id %s", id)
+		}
+		prev = id
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := config.RequestIDConfig{Header: "X-Request-ID"}
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Generated when missing", incoming: ""},
+		{name: "Accepted from header", incoming: "abc-123", keep: true},
+		{name: "Replaced when invalid", incoming: "bad id\nwith newline"},
+		{name: "Replaced when too long", incoming: strings.Repeat("a", maxLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			base := zerolog.New(&buf)
+
+			var forwarded, fromContext string
+			handler := Middleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				forwarded = r.Header.Get("X-Request-ID")
+				fromContext = FromContext(r.Context())
+				log.Ctx(r.Context()).Info().Msg("handled")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			req = req.WithContext(base.WithContext(req.Context()))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get("X-Request-ID")
+			if tt.keep && id != tt.incoming {
+				t.Errorf("response id = %s, want incoming %s", id, tt.incoming)
+			}
+			if !tt.keep && !uuidV7.MatchString(id) {
+				t.Errorf("response id = %q, want generated UUIDv7", id)
+			}
+			if forwarded != id || fromContext != id {
+				t.Errorf("forwarded = %s, context = %s, want %s", forwarded, fromContext, id)
+			}
+			if !strings.Contains(buf.String(), `"request_id":"`+id+`"`) {
+				t.Errorf("request logger output %q should contain request id", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"time"
+)
+
+type tokenBucket struct {
+	tokens int
+	// lastRefill - время последнего пополнения в миллисекундах.
+	lastRefill int64
+}
+
+// takeTokens применяет запрос стоимостью cost к бакету ёмкостью capacity,
+// пополняемому на refillRate токенов в секунду. Время - в миллисекундах, как
+// в tokenBucketScript для Redis.
+func takeTokens(b *tokenBucket, now int64, cost int, capacity int, refillRate int) LimitResult {
+	refillBucket(b, now, capacity, refillRate)
+
+	allowed := b.tokens >= cost
+	if allowed {
+		b.tokens -= cost
+	}
+
+	return bucketResult(b, now, cost, capacity, refillRate, allowed)
+}
+
+// refillBucket добавляет токены, накопившиеся с lastRefill. lastRefill
+// сдвигается ровно на время добавленных токенов, чтобы не терять долю
+// следующего токена; у полного бакета время не копится.
+func refillBucket(b *tokenBucket, now int64, capacity int, refillRate int) {
+	elapsed := max(0, now-b.lastRefill)
+	added := elapsed * int64(refillRate) / 1000
+
+	b.tokens = int(min(int64(capacity), int64(b.tokens)+added))
+	if b.tokens >= capacity {
+		b.lastRefill = now
+	} else {
+		b.lastRefill += added * 1000 / int64(refillRate)
+	}
+}
+
+func bucketResult(b *tokenBucket, now int64, cost int, capacity int, refillRate int, allowed bool) LimitResult {
+	result := LimitResult{
+		Allowed:   allowed,
+		Remaining: b.tokens,
+		Reset:     untilTokens(b, now, capacity, refillRate),
+	}
+	if !allowed {
+		result.RetryAfter = untilTokens(b, now, cost, refillRate)
+	}
+
+	return result
+}
+
+// untilTokens возвращает, через сколько в бакете будет n токенов.
+func untilTokens(b *tokenBucket, now int64, n int, refillRate int) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+
+	rate := int64(refillRate)
+	wait := (int64(n-b.tokens)*1000+rate-1)/rate - (now - b.lastRefill)
+
+	return time.Duration(max(0, wait)) * time.Millisecond
+}
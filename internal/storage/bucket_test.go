@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTakeTokens(t *testing.T) {
+	now := int64(1_800_000_000_000)
+	b := &tokenBucket{tokens: 5, lastRefill: now}
+
+	// Полный бакет ёмкостью 5 при 2 токенах в секунду.
+	for i := 0; i < 5; i++ {
+		if result := takeTokens(b, now, 1, 5, 2); !result.Allowed {
+			t.Fatalf("Request %d should be allowed", i)
+		}
+	}
+
+	result := takeTokens(b, now+200, 1, 5, 2)
+	if result.Allowed {
+		t.Fatal("Expected request over capacity to be denied")
+	}
+	if want := 300 * time.Millisecond; result.RetryAfter != want {
+		t.Errorf("Expected retry after %s, got %s", want, result.RetryAfter)
+	}
+	if want := 2300 * time.Millisecond; result.Reset != want {
+		t.Errorf("Expected reset after %s, got %s", want, result.Reset)
+	}
+
+	// Доля следующего токена не теряется: через 750мс добавлен один токен,
+	// а второй накопится ещё через 250мс.
+	result = takeTokens(b, now+750, 1, 5, 2)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("Expected request to be allowed after refill, got %+v", result)
+	}
+	result = takeTokens(b, now+800, 1, 5, 2)
+	if result.Allowed {
+		t.Fatal("Expected request to be denied before next token")
+	}
+	if want := 200 * time.Millisecond; result.RetryAfter != want {
+		t.Errorf("Expected retry after %s, got %s", want, result.RetryAfter)
+	}
+
+	// Запрос стоимостью 3 ждёт три токена.
+	result = takeTokens(b, now+1000, 3, 5, 2)
+	if result.Allowed || result.Remaining != 1 {
+		t.Fatalf("Expected cost 3 to be denied with 1 token, got %+v", result)
+	}
+	if want := time.Second; result.RetryAfter != want {
+		t.Errorf("Expected retry after %s, got %s", want, result.RetryAfter)
+	}
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"time"
+)
+
+// gcra применяет запрос стоимостью cost по алгоритму GCRA. Состояние -
+// теоретическое время прибытия (TAT) следующего запроса: каждый запрос
+// сдвигает его на cost интервалов 1/refillRate, а запрос разрешён, пока TAT
+// опережает текущее время не больше чем на capacity интервалов. Та же логика
+// реализована в gcraScript для Redis.
+func gcra(tat time.Time, now time.Time, cost int, capacity int, refillRate int) (time.Time, LimitResult) {
+	interval := time.Second / time.Duration(refillRate)
+	tolerance := interval * time.Duration(capacity)
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(interval * time.Duration(cost))
+	allowAt := newTAT.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return tat, LimitResult{
+			Remaining:  gcraRemaining(tat.Sub(now), tolerance, interval),
+			RetryAfter: allowAt.Sub(now),
+			Reset:      tat.Sub(now),
+		}
+	}
+
+	return newTAT, LimitResult{
+		Allowed:   true,
+		Remaining: gcraRemaining(newTAT.Sub(now), tolerance, interval),
+		Reset:     newTAT.Sub(now),
+	}
+}
+
+func gcraRemaining(ahead, tolerance, interval time.Duration) int {
+	return max(0, int((tolerance-ahead)/interval))
+}
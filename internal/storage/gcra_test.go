@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGCRA(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var tat time.Time
+	var result LimitResult
+
+	// Всплеск до capacity, затем 1 запрос в 100мс при refillRate 10.
+	for i := 0; i < 5; i++ {
+		tat, result = gcra(tat, now, 1, 5, 10)
+		if !result.Allowed {
+			t.Fatalf("Request %d should be allowed", i)
+		}
+		if result.Remaining != 4-i {
+			t.Errorf("Request %d: expected %d remaining, got %d", i, 4-i, result.Remaining)
+		}
+	}
+	if want := 500 * time.Millisecond; result.Reset != want {
+		t.Errorf("Expected reset after %s, got %s", want, result.Reset)
+	}
+
+	tat, result = gcra(tat, now.Add(30*time.Millisecond), 1, 5, 10)
+	if result.Allowed {
+		t.Fatal("Expected request over the burst to be denied")
+	}
+	if want := 70 * time.Millisecond; result.RetryAfter != want {
+		t.Errorf("Expected retry after %s, got %s", want, result.RetryAfter)
+	}
+
+	tat, result = gcra(tat, now.Add(100*time.Millisecond), 1, 5, 10)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Expected request to be allowed after one interval, got %+v", result)
+	}
+
+	// Запрос стоимостью 3 ждёт три интервала.
+	_, result = gcra(tat, now.Add(100*time.Millisecond), 3, 5, 10)
+	if result.Allowed || result.RetryAfter != 300*time.Millisecond {
+		t.Errorf("Expected cost 3 to wait 300ms, got %+v", result)
+	}
+
+	// После полного восстановления доступна вся ёмкость.
+	_, result = gcra(tat, now.Add(time.Second), 1, 5, 10)
+	if !result.Allowed || result.Remaining != 4 {
+		t.Errorf("Expected full capacity after idle period, got %+v", result)
+	}
+}
@@ -2,63 +2,218 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 )
 
-type MemoryStorage struct {
-	tokens  map[string]*tokenBucket
-	configs map[string]*clientConfig
-	mu      sync.RWMutex
-}
+// sweepInterval - как часто хранилище в памяти удаляет устаревшие состояния
+// GCRA и скользящего окна, как Redis удаляет истёкшие ключи.
+const sweepInterval = time.Minute
 
-type tokenBucket struct {
-	tokens     int
-	lastRefill time.Time
+type MemoryStorage struct {
+	tokens    map[string]*tokenBucket
+	windows   map[string]*windowState
+	tats      map[string]time.Time
+	leases    map[string]map[string]time.Time
+	configs   map[string]*clientConfig
+	lastSweep time.Time
+	mu        sync.RWMutex
 }
 
 type clientConfig struct {
-	capacity   int
-	refillRate int
+	capacity    int
+	refillRate  int
+	maxInFlight int
+	group       string
 }
 
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
 		tokens:  make(map[string]*tokenBucket),
+		windows: make(map[string]*windowState),
+		tats:    make(map[string]time.Time),
+		leases:  make(map[string]map[string]time.Time),
 		configs: make(map[string]*clientConfig),
 	}
 }
 
-func (s *MemoryStorage) TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (bool, int, error) {
+func (s *MemoryStorage) TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (LimitResult, error) {
+	if refillRate <= 0 {
+		return LimitResult{}, fmt.Errorf("refill rate must be positive")
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	now := time.Now().UnixMilli()
+
+	return takeTokens(s.bucket(ClientKey(key), now, capacity), now, tokensToTake, capacity, refillRate), nil
+}
+
+func (s *MemoryStorage) TakeTokensMulti(ctx context.Context, buckets []Bucket) (int, []LimitResult, error) {
+	for _, b := range buckets {
+		if b.RefillRate <= 0 {
+			return -1, nil, fmt.Errorf("refill rate must be positive")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	states := make([]*tokenBucket, len(buckets))
+	rejected := -1
+
+	for i, b := range buckets {
+		states[i] = s.bucket(b.Key, now, b.Capacity)
+		refillBucket(states[i], now, b.Capacity, b.RefillRate)
+		if rejected < 0 && states[i].tokens < b.Tokens {
+			rejected = i
+		}
+	}
+
+	results := make([]LimitResult, len(buckets))
+	for i, b := range buckets {
+		if rejected < 0 {
+			states[i].tokens -= b.Tokens
+		}
+		results[i] = bucketResult(states[i], now, b.Tokens, b.Capacity, b.RefillRate, rejected < 0)
+	}
+
+	return rejected, results, nil
+}
+
+// bucket возвращает бакет клиента, создавая полный бакет при первом
+// обращении. Вызывается под s.mu.
+func (s *MemoryStorage) bucket(key string, now int64, capacity int) *tokenBucket {
 	bucket, ok := s.tokens[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, lastRefill: now}
+		s.tokens[key] = bucket
+	}
+
+	return bucket
+}
+
+func (s *MemoryStorage) SlidingWindow(ctx context.Context, key string, cost int, limit int, window time.Duration) (LimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	now := time.Now()
+	s.sweep(now)
 
+	state, ok := s.windows[key]
 	if !ok {
-		bucket = &tokenBucket{
-			tokens:     capacity,
-			lastRefill: now,
+		state = &windowState{}
+		s.windows[key] = state
+	}
+
+	return slidingWindow(state, now.UnixMilli(), cost, limit, window), nil
+}
+
+func (s *MemoryStorage) GCRA(ctx context.Context, key string, cost int, capacity int, refillRate int) (LimitResult, error) {
+	if refillRate <= 0 {
+		return LimitResult{}, fmt.Errorf("refill rate must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	tat, result := gcra(s.tats[key], now, cost, capacity, refillRate)
+	s.tats[key] = tat
+
+	return result, nil
+}
+
+// sweep не чаще раза в sweepInterval удаляет состояния, которые уже не
+// влияют на лимиты: TAT в прошлом и скользящие окна, оба счётчика которых
+// истекли. Без этого в памяти оставался бы каждый когда-либо встреченный
+// клиент. Вызывается под s.mu.
+func (s *MemoryStorage) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
 		}
-		s.tokens[key] = bucket
-	} else {
+	}
 
-		elapsed := now.Sub(bucket.lastRefill).Seconds()
-		tokensToAdd := int(elapsed * float64(refillRate))
+	ms := now.UnixMilli()
+	for key, state := range s.windows {
+		if state.expired(ms) {
+			delete(s.windows, key)
+		}
+	}
+}
+
+func (s *MemoryStorage) AcquireLease(ctx context.Context, key string, leaseID string, limit int, ttl time.Duration) (bool, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	leases := s.activeLeases(key, now)
+
+	if len(leases) >= limit {
+		return false, len(leases), nil
+	}
+
+	if leases == nil {
+		leases = make(map[string]time.Time)
+		s.leases[key] = leases
+	}
+	leases[leaseID] = now.Add(ttl)
+
+	return true, len(leases), nil
+}
+
+func (s *MemoryStorage) ReleaseLease(ctx context.Context, key string, leaseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		if tokensToAdd > 0 {
-			bucket.tokens = min(capacity, bucket.tokens+tokensToAdd)
-			bucket.lastRefill = now
+	if leases, ok := s.leases[key]; ok {
+		delete(leases, leaseID)
+		if len(leases) == 0 {
+			delete(s.leases, key)
 		}
 	}
 
-	if bucket.tokens >= tokensToTake {
-		bucket.tokens -= tokensToTake
-		return true, bucket.tokens, nil
+	return nil
+}
+
+func (s *MemoryStorage) InFlight(ctx context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.activeLeases(key, time.Now())), nil
+}
+
+// activeLeases удаляет истёкшие слоты клиента и возвращает оставшиеся.
+// Вызывается под s.mu.
+func (s *MemoryStorage) activeLeases(key string, now time.Time) map[string]time.Time {
+	leases, ok := s.leases[key]
+	if !ok {
+		return nil
+	}
+
+	for id, expires := range leases {
+		if !now.Before(expires) {
+			delete(leases, id)
+		}
 	}
 
-	return false, bucket.tokens, nil
+	if len(leases) == 0 {
+		delete(s.leases, key)
+		return nil
+	}
+
+	return leases
 }
 
 func (s *MemoryStorage) GetClientConfig(ctx context.Context, key string) (capacity int, refillRate int, err error) {
@@ -77,14 +232,85 @@ func (s *MemoryStorage) SetClientConfig(ctx context.Context, key string, capacit
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if capacity == 0 && refillRate == 0 {
+	config, ok := s.configs[key]
+	if !ok {
+		if capacity == 0 && refillRate == 0 {
+			return nil
+		}
+		config = &clientConfig{}
+		s.configs[key] = config
+	}
+
+	config.capacity = capacity
+	config.refillRate = refillRate
+	if *config == (clientConfig{}) {
 		delete(s.configs, key)
-		return nil
 	}
 
-	s.configs[key] = &clientConfig{
-		capacity:   capacity,
-		refillRate: refillRate,
+	return nil
+}
+
+func (s *MemoryStorage) GetClientMaxInFlight(ctx context.Context, key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	config, ok := s.configs[key]
+	if !ok {
+		return 0, nil
+	}
+
+	return config.maxInFlight, nil
+}
+
+func (s *MemoryStorage) SetClientMaxInFlight(ctx context.Context, key string, maxInFlight int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config, ok := s.configs[key]
+	if !ok {
+		if maxInFlight == 0 {
+			return nil
+		}
+		config = &clientConfig{}
+		s.configs[key] = config
+	}
+
+	config.maxInFlight = maxInFlight
+	if *config == (clientConfig{}) {
+		delete(s.configs, key)
+	}
+
+	return nil
+}
+
+func (s *MemoryStorage) GetClientGroup(ctx context.Context, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	config, ok := s.configs[key]
+	if !ok {
+		return "", nil
+	}
+
+	return config.group, nil
+}
+
+func (s *MemoryStorage) SetClientGroup(ctx context.Context, key string, group string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config, ok := s.configs[key]
+	if !ok {
+		if group == "" {
+			return nil
+		}
+		config = &clientConfig{}
+		s.configs[key] = config
+	}
+
+	config.group = group
+	if *config == (clientConfig{}) {
+		delete(s.configs, key)
 	}
 
 	return nil
@@ -7,9 +7,13 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"go-cloud-camp-2025-test-assignment/internal/metrics"
+	"go-cloud-camp-2025-test-assignment/pkg/logger"
 	redisClient "go-cloud-camp-2025-test-assignment/pkg/redis"
 )
 
+var log = logger.For(logger.Storage)
+
 type RedisStorage struct {
 	client *redisClient.Client
 }
@@ -28,65 +32,444 @@ func NewRedisStorage(client *redisClient.Client) (*RedisStorage, error) {
 	}, nil
 }
 
-const tokenBucketScript = `
+// tokenBucketFunctions повторяет refillBucket и untilTokens из bucket.go.
+const tokenBucketFunctions = `
+local function refill(key, now, capacity, refillRate)
+	local tokens = tonumber(redis.call('HGET', key, 'tokens') or capacity)
+	local lastRefill = tonumber(redis.call('HGET', key, 'lastRefill') or now)
+
+	-- Время сдвигается ровно на добавленные токены, чтобы не терять долю
+	-- следующего токена; у полного бакета время не копится
+	local elapsed = math.max(0, now - lastRefill)
+	local added = math.floor(elapsed * refillRate / 1000)
+
+	tokens = math.min(capacity, tokens + added)
+	if tokens >= capacity then
+		lastRefill = now
+	else
+		lastRefill = lastRefill + math.floor(added * 1000 / refillRate)
+	end
+
+	return tokens, lastRefill
+end
+
+-- Через сколько миллисекунд в бакете будет n токенов
+local function untilTokens(tokens, lastRefill, now, n, refillRate)
+	if tokens >= n then
+		return 0
+	end
+	return math.max(0, math.ceil((n - tokens) * 1000 / refillRate) - (now - lastRefill))
+end
+
+-- Бакет хранится, пока не наполнится: отсутствующий ключ равен полному
+-- бакету, поэтому ключи ушедших клиентов удаляются сами
+local function save(key, tokens, lastRefill, now, capacity, refillRate)
+	redis.call('HSET', key, 'tokens', tokens, 'lastRefill', lastRefill)
+	redis.call('PEXPIRE', key, math.max(1, untilTokens(tokens, lastRefill, now, capacity, refillRate)))
+end
+`
+
+const tokenBucketScript = tokenBucketFunctions + `
 local key = KEYS[1]
 local tokensToTake = tonumber(ARGV[1])
 local capacity = tonumber(ARGV[2])
 local refillRate = tonumber(ARGV[3])
 local now = tonumber(ARGV[4])
 
--- Получаем текущее состояние
-local tokens = tonumber(redis.call('HGET', key, 'tokens') or capacity)
-local lastRefill = tonumber(redis.call('HGET', key, 'lastRefill') or now)
-
--- Вычисляем, сколько времени прошло и сколько токенов нужно добавить
-local elapsed = math.max(0, now - lastRefill)
-local tokensToAdd = math.min(capacity - tokens, math.floor(elapsed * refillRate / 1000))
-
--- Обновляем количество токенов
-tokens = math.min(capacity, tokens + tokensToAdd)
+local tokens, lastRefill = refill(key, now, capacity, refillRate)
 
 -- Проверяем, можно ли взять токены
 local allowed = tokens >= tokensToTake
+local retryAfter = 0
 
--- Если можно, уменьшаем количество токенов
 if allowed then
 	tokens = tokens - tokensToTake
+else
+	retryAfter = untilTokens(tokens, lastRefill, now, tokensToTake, refillRate)
 end
 
 -- Сохраняем обновленное состояние
-redis.call('HSET', key, 'tokens', tokens)
-redis.call('HSET', key, 'lastRefill', now)
+save(key, tokens, lastRefill, now, capacity, refillRate)
 
-return {allowed and 1 or 0, tokens}
+return {allowed and 1 or 0, tokens, retryAfter, untilTokens(tokens, lastRefill, now, capacity, refillRate)}
 `
 
-func (s *RedisStorage) TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (bool, int, error) {
+func (s *RedisStorage) TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (result LimitResult, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "take_tokens", start, err) }()
+
+	if refillRate <= 0 {
+		return LimitResult{}, fmt.Errorf("refill rate must be positive")
+	}
+
 	key = RateLimitKey(key)
 	now := time.Now().UnixMilli()
 
-	result, err := s.client.ExecLuaScript(
+	raw, err := s.client.ExecLuaScript(
 		ctx,
 		tokenBucketScript,
 		[]string{key},
 		tokensToTake, capacity, refillRate, now,
 	)
 	if err != nil {
-		return false, 0, fmt.Errorf("failed to execute token bucket script: %w", err)
+		return LimitResult{}, fmt.Errorf("failed to execute token bucket script: %w", err)
+	}
+
+	values, err := int64Results(raw, 4)
+	if err != nil {
+		return LimitResult{}, err
+	}
+
+	return LimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// tokenBucketMultiScript - tokenBucketScript для нескольких бакетов сразу.
+// Токены списываются, только если их хватает во всех бакетах. Возвращает
+// номер первого бакета, где токенов не хватило (с единицы, 0 - хватило
+// везде), и для каждого бакета остаток, время до нужного числа токенов и
+// время до полного бакета.
+//
+// Ключи одного вызова принадлежат разным клиентам и общим лимитам, поэтому
+// скрипт работает только с Redis без кластера: в Redis Cluster он получит
+// CROSSSLOT, а хеш-тег общего бакета свёл бы в один слот все ключи.
+const tokenBucketMultiScript = tokenBucketFunctions + `
+local now = tonumber(ARGV[1])
+local states = {}
+local rejected = 0
+
+for i, key in ipairs(KEYS) do
+	local tokensToTake = tonumber(ARGV[2 + (i - 1) * 3])
+	local capacity = tonumber(ARGV[3 + (i - 1) * 3])
+	local refillRate = tonumber(ARGV[4 + (i - 1) * 3])
+
+	local tokens, lastRefill = refill(key, now, capacity, refillRate)
+
+	if rejected == 0 and tokens < tokensToTake then
+		rejected = i
+	end
+
+	states[i] = {tokens, lastRefill, tokensToTake, capacity, refillRate}
+end
+
+local result = {rejected}
+for i, key in ipairs(KEYS) do
+	local tokens, lastRefill, tokensToTake, capacity, refillRate = unpack(states[i])
+	local retryAfter = 0
+
+	if rejected == 0 then
+		tokens = tokens - tokensToTake
+	else
+		retryAfter = untilTokens(tokens, lastRefill, now, tokensToTake, refillRate)
+	end
+
+	save(key, tokens, lastRefill, now, capacity, refillRate)
+
+	result[#result + 1] = tokens
+	result[#result + 1] = retryAfter
+	result[#result + 1] = untilTokens(tokens, lastRefill, now, capacity, refillRate)
+end
+
+return result
+`
+
+func (s *RedisStorage) TakeTokensMulti(ctx context.Context, buckets []Bucket) (rejected int, results []LimitResult, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "take_tokens_multi", start, err) }()
+
+	keys := make([]string, len(buckets))
+	args := make([]interface{}, 0, 1+len(buckets)*3)
+	args = append(args, time.Now().UnixMilli())
+	for i, b := range buckets {
+		if b.RefillRate <= 0 {
+			return -1, nil, fmt.Errorf("refill rate must be positive")
+		}
+		keys[i] = RateLimitPrefix + b.Key
+		args = append(args, b.Tokens, b.Capacity, b.RefillRate)
+	}
+
+	raw, err := s.client.ExecLuaScript(ctx, tokenBucketMultiScript, keys, args...)
+	if err != nil {
+		return -1, nil, fmt.Errorf("failed to execute token bucket multi script: %w", err)
+	}
+
+	values, err := int64Results(raw, 1+len(buckets)*3)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	rejected = int(values[0]) - 1
+	results = make([]LimitResult, len(buckets))
+	for i := range buckets {
+		v := values[1+i*3:]
+		results[i] = LimitResult{
+			Allowed:    rejected < 0,
+			Remaining:  int(v[0]),
+			RetryAfter: time.Duration(v[1]) * time.Millisecond,
+			Reset:      time.Duration(v[2]) * time.Millisecond,
+		}
+	}
+
+	return rejected, results, nil
+}
+
+// slidingWindowScript повторяет slidingWindow из window.go. Состояние -
+// хэш с началом текущего окна и счётчиками двух окон; ключ живёт два окна.
+const slidingWindowScript = `
+local key = KEYS[1]
+local cost = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local size = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local start = now - now % size
+local state = redis.call('HMGET', key, 'start', 'current', 'previous')
+local stateStart = tonumber(state[1]) or 0
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+
+if stateStart ~= start then
+	if stateStart == start - size then
+		previous = current
+	else
+		previous = 0
+	end
+	current = 0
+end
+
+local elapsed = now - start
+local estimate = previous * (size - elapsed) / size + current
+
+local allowed = estimate + cost <= limit
+if allowed then
+	current = current + cost
+	estimate = estimate + cost
+end
+
+redis.call('HSET', key, 'start', start, 'current', current, 'previous', previous)
+redis.call('PEXPIRE', key, 2 * size)
+
+local reset = 0
+if current > 0 then
+	reset = 2 * size - elapsed
+elseif previous > 0 then
+	reset = size - elapsed
+end
+
+local retryAfter = 0
+if not allowed then
+	local need = limit - cost
+	if need < 0 then
+		retryAfter = reset
+	elseif current <= need then
+		retryAfter = size - elapsed - (need - current) * size / previous
+	else
+		retryAfter = size - elapsed + math.max(0, size - need * size / current)
+	end
+	retryAfter = math.max(1, math.ceil(retryAfter))
+end
+
+local remaining = math.max(0, math.floor(limit - estimate))
+
+return {allowed and 1 or 0, remaining, retryAfter, math.ceil(reset)}
+`
+
+func (s *RedisStorage) SlidingWindow(ctx context.Context, key string, cost int, limit int, window time.Duration) (result LimitResult, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "sliding_window", start, err) }()
+
+	raw, err := s.client.ExecLuaScript(
+		ctx,
+		slidingWindowScript,
+		[]string{SlidingWindowKey(key)},
+		cost, limit, window.Milliseconds(), time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return LimitResult{}, fmt.Errorf("failed to execute sliding window script: %w", err)
+	}
+
+	values, err := int64Results(raw, 4)
+	if err != nil {
+		return LimitResult{}, err
+	}
+
+	return LimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// gcraScript повторяет gcra из gcra.go. Время в микросекундах; TAT
+// хранится одним ключом, который истекает, когда лимит восстановится
+// полностью. Значение записывается через %.0f: обычное преобразование числа
+// в строку в Lua теряет точность.
+const gcraScript = `
+local key = KEYS[1]
+local cost = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local refillRate = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local interval = 1000000 / refillRate
+local tolerance = interval * capacity
+
+local tat = tonumber(redis.call('GET', key)) or now
+if tat < now then
+	tat = now
+end
+
+local newTat = tat + interval * cost
+local allowAt = newTat - tolerance
+
+if now < allowAt then
+	local remaining = math.max(0, math.floor((tolerance - (tat - now)) / interval))
+	return {0, remaining, math.ceil(allowAt - now), math.ceil(tat - now)}
+end
+
+redis.call('SET', key, string.format('%.0f', newTat), 'PX', math.ceil((newTat - now) / 1000))
+
+local remaining = math.max(0, math.floor((tolerance - (newTat - now)) / interval))
+return {1, remaining, 0, math.ceil(newTat - now)}
+`
+
+func (s *RedisStorage) GCRA(ctx context.Context, key string, cost int, capacity int, refillRate int) (result LimitResult, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "gcra", start, err) }()
+
+	if refillRate <= 0 {
+		return LimitResult{}, fmt.Errorf("refill rate must be positive")
+	}
+
+	raw, err := s.client.ExecLuaScript(
+		ctx,
+		gcraScript,
+		[]string{GCRAKey(key)},
+		cost, capacity, refillRate, time.Now().UnixMicro(),
+	)
+	if err != nil {
+		return LimitResult{}, fmt.Errorf("failed to execute gcra script: %w", err)
+	}
+
+	values, err := int64Results(raw, 4)
+	if err != nil {
+		return LimitResult{}, err
+	}
+
+	return LimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		Reset:      time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// acquireLeaseScript хранит слоты клиента в sorted set: элемент -
+// идентификатор слота, вес - время его истечения. Истёкшие слоты удаляются
+// перед подсчётом, поэтому слоты упавшего экземпляра не занимают лимит
+// дольше ttl.
+const acquireLeaseScript = `
+local key = KEYS[1]
+local leaseID = ARGV[1]
+local limit = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+
+local inFlight = redis.call('ZCARD', key)
+if inFlight >= limit then
+	return {0, inFlight}
+end
+
+redis.call('ZADD', key, now + ttl, leaseID)
+redis.call('PEXPIRE', key, ttl)
+
+return {1, inFlight + 1}
+`
+
+func (s *RedisStorage) AcquireLease(ctx context.Context, key string, leaseID string, limit int, ttl time.Duration) (acquired bool, inFlight int, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "acquire_lease", start, err) }()
+
+	raw, err := s.client.ExecLuaScript(
+		ctx,
+		acquireLeaseScript,
+		[]string{InFlightKey(key)},
+		leaseID, limit, ttl.Milliseconds(), time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to execute acquire lease script: %w", err)
+	}
+
+	values, err := int64Results(raw, 2)
+	if err != nil {
+		return false, 0, err
+	}
+
+	return values[0] == 1, int(values[1]), nil
+}
+
+func (s *RedisStorage) ReleaseLease(ctx context.Context, key string, leaseID string) (err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "release_lease", start, err) }()
+
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
+		return client.ZRem(ctx, InFlightKey(key), leaseID).Err()
+	}, 3)
+	if err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
 	}
 
-	resultArray, ok := result.([]interface{})
-	if !ok || len(resultArray) != 2 {
-		return false, 0, fmt.Errorf("unexpected result from Redis: %v", result)
+	return nil
+}
+
+func (s *RedisStorage) InFlight(ctx context.Context, key string) (inFlight int, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "in_flight", start, err) }()
+
+	var count int64
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
+		var err error
+		now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		count, err = client.ZCount(ctx, InFlightKey(key), "("+now, "+inf").Result()
+		return err
+	}, 3)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count in-flight requests: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// int64Results разбирает ответ Lua-скрипта - массив из n целых чисел.
+func int64Results(raw interface{}, n int) ([]int64, error) {
+	array, ok := raw.([]interface{})
+	if !ok || len(array) != n {
+		return nil, fmt.Errorf("unexpected result from Redis: %v", raw)
 	}
 
-	allowed := resultArray[0].(int64) == 1
-	tokens := int(resultArray[1].(int64))
+	values := make([]int64, n)
+	for i, v := range array {
+		value, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result from Redis: %v", raw)
+		}
+		values[i] = value
+	}
 
-	return allowed, tokens, nil
+	return values, nil
 }
 
 func (s *RedisStorage) GetClientConfig(ctx context.Context, key string) (capacity int, refillRate int, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "get_client_config", start, err) }()
+
 	configKey := ConfigKey(key)
 
 	var result map[string]string
@@ -110,10 +493,13 @@ func (s *RedisStorage) GetClientConfig(ctx context.Context, key string) (capacit
 	return capacity, refillRate, nil
 }
 
-func (s *RedisStorage) SetClientConfig(ctx context.Context, key string, capacity int, refillRate int) error {
+func (s *RedisStorage) SetClientConfig(ctx context.Context, key string, capacity int, refillRate int) (err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "set_client_config", start, err) }()
+
 	configKey := ConfigKey(key)
 
-	err := s.client.WithRetry(ctx, func(client *redis.Client) error {
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
 		_, err := client.HSet(ctx, configKey, map[string]interface{}{
 			"capacity":   capacity,
 			"refillRate": refillRate,
@@ -128,10 +514,97 @@ func (s *RedisStorage) SetClientConfig(ctx context.Context, key string, capacity
 	return nil
 }
 
-func (s *RedisStorage) Ping(ctx context.Context) error {
+func (s *RedisStorage) GetClientMaxInFlight(ctx context.Context, key string) (maxInFlight int, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "get_client_max_in_flight", start, err) }()
+
+	var value string
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
+		var err error
+		value, err = client.HGet(ctx, ConfigKey(key), "maxInFlight").Result()
+		if err == redis.Nil {
+			value, err = "", nil
+		}
+		return err
+	}, 3)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get client max in-flight: %w", err)
+	}
+
+	maxInFlight, _ = strconv.Atoi(value)
+
+	return maxInFlight, nil
+}
+
+func (s *RedisStorage) SetClientMaxInFlight(ctx context.Context, key string, maxInFlight int) (err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "set_client_max_in_flight", start, err) }()
+
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
+		return client.HSet(ctx, ConfigKey(key), "maxInFlight", maxInFlight).Err()
+	}, 3)
+	if err != nil {
+		return fmt.Errorf("failed to set client max in-flight: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStorage) GetClientGroup(ctx context.Context, key string) (group string, err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "get_client_group", start, err) }()
+
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
+		var err error
+		group, err = client.HGet(ctx, ConfigKey(key), "group").Result()
+		if err == redis.Nil {
+			group, err = "", nil
+		}
+		return err
+	}, 3)
+	if err != nil {
+		return "", fmt.Errorf("failed to get client group: %w", err)
+	}
+
+	return group, nil
+}
+
+func (s *RedisStorage) SetClientGroup(ctx context.Context, key string, group string) (err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "set_client_group", start, err) }()
+
+	err = s.client.WithRetry(ctx, func(client *redis.Client) error {
+		if group == "" {
+			return client.HDel(ctx, ConfigKey(key), "group").Err()
+		}
+		return client.HSet(ctx, ConfigKey(key), "group", group).Err()
+	}, 3)
+	if err != nil {
+		return fmt.Errorf("failed to set client group: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStorage) Ping(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() { observe(ctx, "ping", start, err) }()
+
 	return s.client.Ping(ctx)
 }
 
+// observe записывает метрики операции с Redis и пишет её в лог подсистемы
+// storage на уровне debug.
+func observe(ctx context.Context, op string, start time.Time, err error) {
+	metrics.ObserveStorage(op, start, err)
+
+	log.Ctx(ctx).Debug().
+		Str("op", op).
+		Dur("duration", time.Since(start)).
+		Err(err).
+		Msg("Redis storage operation")
+}
+
 func (s *RedisStorage) Close() error {
 	return s.client.Close()
 }
@@ -2,27 +2,115 @@ package storage
 
 import (
 	"context"
+	"time"
 )
 
 type Storage interface {
-	TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (bool, int, error)
+	// TakeTokens берёт tokensToTake токенов из бакета клиента. RetryAfter
+	// результата - через сколько накопится нужное число токенов.
+	TakeTokens(ctx context.Context, key string, tokensToTake int, capacity int, refillRate int) (LimitResult, error)
+
+	// TakeTokensMulti атомарно берёт токены из нескольких бакетов: либо из
+	// всех сразу, либо ни из одного. rejected - индекс первого бакета, где
+	// токенов не хватило, или -1; results - состояние каждого бакета.
+	TakeTokensMulti(ctx context.Context, buckets []Bucket) (rejected int, results []LimitResult, err error)
+
+	// SlidingWindow учитывает cost запросов в скользящем окне длиной window,
+	// не больше limit за окно.
+	SlidingWindow(ctx context.Context, key string, cost int, limit int, window time.Duration) (LimitResult, error)
+
+	// GCRA учитывает cost запросов по алгоритму GCRA с теми же параметрами,
+	// что и у token bucket.
+	GCRA(ctx context.Context, key string, cost int, capacity int, refillRate int) (LimitResult, error)
+
+	// AcquireLease занимает один из limit слотов клиента под идентификатором
+	// leaseID. Слот освобождается ReleaseLease или сам истекает через ttl,
+	// если экземпляр балансировщика упал, не освободив его.
+	AcquireLease(ctx context.Context, key string, leaseID string, limit int, ttl time.Duration) (acquired bool, inFlight int, err error)
+
+	ReleaseLease(ctx context.Context, key string, leaseID string) error
+
+	// InFlight возвращает число занятых слотов клиента.
+	InFlight(ctx context.Context, key string) (int, error)
 
 	GetClientConfig(ctx context.Context, key string) (capacity int, refillRate int, err error)
 
 	SetClientConfig(ctx context.Context, key string, capacity int, refillRate int) error
 
+	// GetClientMaxInFlight возвращает лимит одновременных запросов клиента,
+	// 0 - лимит не задан.
+	GetClientMaxInFlight(ctx context.Context, key string) (int, error)
+
+	SetClientMaxInFlight(ctx context.Context, key string, maxInFlight int) error
+
+	// GetClientGroup возвращает группу (план) клиента, пустая строка - клиент
+	// не в группе.
+	GetClientGroup(ctx context.Context, key string) (string, error)
+
+	SetClientGroup(ctx context.Context, key string, group string) error
+
 	Ping(ctx context.Context) error
 
 	Close() error
 }
 
+// Bucket - бакет в TakeTokensMulti.
+type Bucket struct {
+	// Key - ключ из ClientKey или LayerKey.
+	Key        string
+	Tokens     int
+	Capacity   int
+	RefillRate int
+}
+
+// LimitResult - решение хранилища по запросу.
+type LimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter - через сколько запрос той же стоимости будет разрешён,
+	// если сейчас отклонён.
+	RetryAfter time.Duration
+	// Reset - через сколько лимит восстановится полностью.
+	Reset time.Duration
+}
+
 const (
-	RateLimitPrefix = "ratelimit:"
-	ConfigPrefix    = "config:"
+	RateLimitPrefix     = "ratelimit:"
+	ConfigPrefix        = "config:"
+	SlidingWindowPrefix = "sw:"
+	GCRAPrefix          = "gcra:"
+	InFlightPrefix      = "inflight:"
+	LayerPrefix         = "layer:"
+	ClientPrefix        = "client:"
 )
 
+// ClientKey - ключ бакета клиента. Идентификатор клиента приходит из запроса
+// как есть, поэтому бакеты клиентов вынесены под отдельный префикс и не
+// совпадают с бакетами LayerKey, какой бы идентификатор ни прислал клиент.
+func ClientKey(clientID string) string {
+	return ClientPrefix + clientID
+}
+
 func RateLimitKey(clientID string) string {
-	return RateLimitPrefix + clientID
+	return RateLimitPrefix + ClientKey(clientID)
+}
+
+func SlidingWindowKey(clientID string) string {
+	return RateLimitPrefix + SlidingWindowPrefix + clientID
+}
+
+func GCRAKey(clientID string) string {
+	return RateLimitPrefix + GCRAPrefix + clientID
+}
+
+// LayerKey - ключ бакета общего лимита (глобального или маршрута) для
+// TakeTokensMulti.
+func LayerKey(name string) string {
+	return LayerPrefix + name
+}
+
+func InFlightKey(clientID string) string {
+	return RateLimitPrefix + InFlightPrefix + clientID
 }
 
 func ConfigKey(clientID string) string {
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeys_ClientIDCannotCollide(t *testing.T) {
+	internal := map[string]bool{
+		RateLimitPrefix + LayerKey("global"): true,
+		ConfigKey("victim"):                  true,
+		SlidingWindowKey("victim"):           true,
+		GCRAKey("victim"):                    true,
+		InFlightKey("victim"):                true,
+		RateLimitKey("victim"):               true,
+	}
+
+	hostile := []string{"layer:global", "config:victim", "sw:victim", "gcra:victim", "inflight:victim", "client:victim"}
+	for _, id := range hostile {
+		if internal[RateLimitKey(id)] {
+			t.Errorf("RateLimitKey(%q) = %q collides with another key", id, RateLimitKey(id))
+		}
+	}
+}
+
+func TestMemoryStorage_ClientBucketSeparateFromLayer(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage()
+	defer store.Close()
+
+	rejected, _, err := store.TakeTokensMulti(ctx, []Bucket{
+		{Key: LayerKey("global"), Tokens: 1, Capacity: 1, RefillRate: 1},
+		{Key: ClientKey("client1"), Tokens: 1, Capacity: 10, RefillRate: 1},
+	})
+	if err != nil || rejected != -1 {
+		t.Fatalf("Expected layered request to be allowed, got %d, %v", rejected, err)
+	}
+
+	// Клиент с идентификатором, совпадающим с ключом глобального бакета,
+	// получает собственный бакет.
+	result, err := store.TakeTokens(ctx, "layer:global", 1, 5, 1)
+	if err != nil {
+		t.Fatalf("TakeTokens failed: %v", err)
+	}
+	if !result.Allowed || result.Remaining != 4 {
+		t.Errorf("Expected separate client bucket with 4 tokens left, got %+v", result)
+	}
+
+	// Бакет клиента в TakeTokensMulti общий с TakeTokens.
+	result, err = store.TakeTokens(ctx, "client1", 0, 10, 1)
+	if err != nil {
+		t.Fatalf("TakeTokens failed: %v", err)
+	}
+	if result.Remaining != 9 {
+		t.Errorf("Expected client1 bucket to be shared, got %d tokens left", result.Remaining)
+	}
+}
+
+func TestMemoryStorage_SweepStaleState(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage()
+	defer store.Close()
+
+	// TAT опережает текущее время на 1 мс и на 1 с.
+	store.GCRA(ctx, "fast", 1, 10, 1000)
+	store.GCRA(ctx, "slow", 1, 10, 1)
+	store.SlidingWindow(ctx, "short", 1, 10, 10*time.Millisecond)
+	store.SlidingWindow(ctx, "long", 1, 10, time.Hour)
+
+	// Первое обращение уже запустило очистку, следующая - через sweepInterval.
+	store.mu.Lock()
+	store.sweep(time.Now().Add(100 * time.Millisecond))
+	if len(store.tats) != 2 || len(store.windows) != 2 {
+		t.Errorf("Expected sweep to wait for sweepInterval, got %d TATs and %d windows", len(store.tats), len(store.windows))
+	}
+	store.lastSweep = time.Time{}
+	store.sweep(time.Now().Add(100 * time.Millisecond))
+	store.mu.Unlock()
+
+	if _, ok := store.tats["fast"]; ok {
+		t.Error("Expected TAT in the past to be dropped")
+	}
+	if _, ok := store.tats["slow"]; !ok {
+		t.Error("Expected TAT in the future to be kept")
+	}
+	if _, ok := store.windows["short"]; ok {
+		t.Error("Expected expired window to be dropped")
+	}
+	if _, ok := store.windows["long"]; !ok {
+		t.Error("Expected current window to be kept")
+	}
+
+	store.mu.Lock()
+	store.sweep(time.Now().Add(sweepInterval + 2*time.Second))
+	store.mu.Unlock()
+
+	if len(store.tats) != 0 {
+		t.Errorf("Expected all TATs to be dropped, got %d", len(store.tats))
+	}
+}
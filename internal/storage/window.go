@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"math"
+	"time"
+)
+
+// windowState - счётчики двух соседних фиксированных окон. Скользящее окно
+// приближается так: запросы предыдущего окна учитываются с весом доли,
+// которую оно ещё занимает в скользящем окне.
+type windowState struct {
+	start    int64 // начало текущего окна, мс
+	size     int64 // длина окна, мс
+	current  int
+	previous int
+}
+
+// expired сообщает, что оба окна закончились и состояние не влияет на лимит.
+func (state *windowState) expired(now int64) bool {
+	return now >= state.start+2*state.size
+}
+
+// slidingWindow применяет запрос стоимостью cost к состоянию в момент now
+// (мс). Та же логика реализована в slidingWindowScript для Redis.
+func slidingWindow(state *windowState, now int64, cost int, limit int, window time.Duration) LimitResult {
+	size := window.Milliseconds()
+	start := now - now%size
+	state.size = size
+
+	if state.start != start {
+		if state.start == start-size {
+			state.previous = state.current
+		} else {
+			state.previous = 0
+		}
+		state.current = 0
+		state.start = start
+	}
+
+	elapsed := float64(now - start)
+	weight := (float64(size) - elapsed) / float64(size)
+	estimate := float64(state.previous)*weight + float64(state.current)
+
+	result := LimitResult{Allowed: estimate+float64(cost) <= float64(limit)}
+	if result.Allowed {
+		state.current += cost
+		estimate += float64(cost)
+	}
+
+	result.Remaining = max(0, int(math.Floor(float64(limit)-estimate)))
+	result.Reset = windowReset(state, elapsed, size)
+	if !result.Allowed {
+		result.RetryAfter = windowRetryAfter(state, elapsed, size, cost, limit)
+	}
+
+	return result
+}
+
+// windowReset - время, через которое оценка опустится до нуля: вклад
+// предыдущего окна исчезает к концу текущего, вклад текущего - к концу
+// следующего.
+func windowReset(state *windowState, elapsed float64, size int64) time.Duration {
+	var ms float64
+	switch {
+	case state.current > 0:
+		ms = 2*float64(size) - elapsed
+	case state.previous > 0:
+		ms = float64(size) - elapsed
+	}
+	return time.Duration(math.Ceil(ms)) * time.Millisecond
+}
+
+// windowRetryAfter - время, через которое оценка опустится до limit-cost.
+func windowRetryAfter(state *windowState, elapsed float64, size int64, cost int, limit int) time.Duration {
+	need := float64(limit - cost)
+	if need < 0 {
+		// Запрос дороже лимита не пройдёт никогда.
+		return windowReset(state, elapsed, size)
+	}
+
+	w := float64(size)
+	var ms float64
+	if float64(state.current) <= need {
+		// Хватит затухания предыдущего окна в пределах текущего.
+		ms = w - elapsed - (need-float64(state.current))*w/float64(state.previous)
+	} else {
+		// Нужно дождаться следующего окна, где текущее станет предыдущим.
+		ms = w - elapsed + max(0, w-need*w/float64(state.current))
+	}
+
+	return time.Duration(math.Ceil(max(ms, 1))) * time.Millisecond
+}
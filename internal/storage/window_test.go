@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	window := time.Minute
+	state := &windowState{}
+
+	// Первое окно: 10 запросов при лимите 10.
+	base := int64(1_800_000_000_000) // начало минуты
+	for i := 0; i < 10; i++ {
+		if result := slidingWindow(state, base+int64(i)*1000, 1, 10, window); !result.Allowed {
+			t.Fatalf("Request %d should be allowed", i)
+		}
+	}
+
+	result := slidingWindow(state, base+30_000, 1, 10, window)
+	if result.Allowed {
+		t.Fatal("Expected request over the limit to be denied")
+	}
+	if result.Remaining != 0 {
+		t.Errorf("Expected 0 remaining, got %d", result.Remaining)
+	}
+	// Оценка опустится до 9 через 6 секунд после начала следующего окна.
+	if want := 36 * time.Second; result.RetryAfter != want {
+		t.Errorf("Expected retry after %s, got %s", want, result.RetryAfter)
+	}
+	if want := 90 * time.Second; result.Reset != want {
+		t.Errorf("Expected reset after %s, got %s", want, result.Reset)
+	}
+
+	// Середина следующего окна: предыдущее учитывается с весом 0.5.
+	result = slidingWindow(state, base+90_000, 1, 10, window)
+	if !result.Allowed {
+		t.Fatal("Expected request to be allowed after half of the previous window expired")
+	}
+	if result.Remaining != 4 {
+		t.Errorf("Expected 4 remaining, got %d", result.Remaining)
+	}
+
+	// Через окно без запросов состояние сбрасывается.
+	result = slidingWindow(state, base+300_000, 1, 10, window)
+	if !result.Allowed || result.Remaining != 9 {
+		t.Errorf("Expected fresh window with 9 remaining, got %+v", result)
+	}
+}
+
+func TestSlidingWindow_RetryAfterWithinWindow(t *testing.T) {
+	window := time.Minute
+	base := int64(1_800_000_000_000)
+	state := &windowState{start: base, previous: 10, current: 2}
+
+	// Оценка в начале окна: 10 + 2 = 12 при лимите 10.
+	result := slidingWindow(state, base, 1, 10, window)
+	if result.Allowed {
+		t.Fatal("Expected request to be denied")
+	}
+	// Нужно, чтобы 10*(1-t/60s) + 2 <= 9: t >= 18s.
+	if want := 18 * time.Second; result.RetryAfter != want {
+		t.Errorf("Expected retry after %s, got %s", want, result.RetryAfter)
+	}
+
+	if result := slidingWindow(state, base+18_000, 1, 10, window); !result.Allowed {
+		t.Errorf("Expected request to be allowed after retry delay, got %+v", result)
+	}
+}
@@ -0,0 +1,278 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+const scopeName = "go-cloud-camp-2025-test-assignment/internal/tracing"
+
+// OTLPExporter отправляет спаны в коллектор по OTLP/HTTP в JSON-кодировке.
+type OTLPExporter struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+}
+
+func NewOTLPExporter(cfg config.TracingConfig) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint:    cfg.Endpoint,
+		headers:     cfg.Headers,
+		serviceName: cfg.ServiceName,
+		client:      &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	TraceState        string          `json:"traceState,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (e *OTLPExporter) encode(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		s := otlpSpan{
+			TraceID:           span.spanContext.TraceID.String(),
+			SpanID:            span.spanContext.SpanID.String(),
+			TraceState:        span.spanContext.TraceState,
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: span.status, Message: span.statusMessage},
+		}
+		if span.parentID.IsValid() {
+			s.ParentSpanID = span.parentID.String()
+		}
+		for _, attr := range span.attributes {
+			s.Attributes = append(s.Attributes, encodeAttribute(attr))
+		}
+		span.mu.Unlock()
+
+		encoded = append(encoded, s)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{encodeAttribute(String("service.name", e.serviceName))},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: encoded,
+			}},
+		}},
+	}
+}
+
+func encodeAttribute(attr Attribute) otlpAttribute {
+	var value otlpValue
+	switch v := attr.Value.(type) {
+	case string:
+		value.StringValue = &v
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		value.IntValue = &s
+	case bool:
+		value.BoolValue = &v
+	case float64:
+		value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		value.StringValue = &s
+	}
+
+	return otlpAttribute{Key: attr.Key, Value: value}
+}
+
+// BatchExporter накапливает завершённые спаны и отправляет их пачками,
+// чтобы экспорт не задерживал обработку запросов. При переполнении очереди
+// спаны отбрасываются.
+type BatchExporter struct {
+	exporter  *OTLPExporter
+	batchSize int
+	interval  time.Duration
+	queue     chan *Span
+	flush     chan chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewBatchExporter(exporter *OTLPExporter, batchSize int, interval time.Duration) *BatchExporter {
+	b := &BatchExporter{
+		exporter:  exporter,
+		batchSize: batchSize,
+		interval:  interval,
+		queue:     make(chan *Span, batchSize*4),
+		flush:     make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go b.run()
+
+	return b
+}
+
+func (b *BatchExporter) enqueue(span *Span) {
+	select {
+	case b.queue <- span:
+	default:
+		log.Warn().Str("span", span.name).Msg("Trace export queue is full, dropping span")
+	}
+}
+
+func (b *BatchExporter) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, b.batchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.exporter.client.Timeout)
+		if err := b.exporter.Export(ctx, batch); err != nil {
+			log.Error().Err(err).Int("spans", len(batch)).Msg("Failed to export spans")
+		}
+		cancel()
+
+		batch = make([]*Span, 0, b.batchSize)
+	}
+
+	for {
+		select {
+		case span := <-b.queue:
+			batch = append(batch, span)
+			if len(batch) >= b.batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-b.flush:
+			for len(b.queue) > 0 {
+				batch = append(batch, <-b.queue)
+			}
+			export()
+			close(ack)
+		case <-b.done:
+			for len(b.queue) > 0 {
+				batch = append(batch, <-b.queue)
+			}
+			export()
+			return
+		}
+	}
+}
+
+// Flush отправляет накопленные спаны и ждёт завершения отправки.
+func (b *BatchExporter) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case b.flush <- ack:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *BatchExporter) Shutdown(ctx context.Context) error {
+	err := b.Flush(ctx)
+	b.closeOnce.Do(func() { close(b.done) })
+	return err
+}
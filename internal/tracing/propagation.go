@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+
+	flagSampled byte = 0x01
+)
+
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext - неизменяемая часть спана, которая передаётся между сервисами
+// в заголовках W3C Trace Context.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	Remote     bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+// Traceparent форматирует заголовок traceparent версии 00.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceparent разбирает заголовок traceparent. Заголовки будущих версий
+// принимаются, если их начало совпадает с форматом версии 00.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 {
+		return SpanContext{}, false
+	}
+
+	version, err := hex.DecodeString(value[:2])
+	if err != nil || version[0] == 0xff || value[2] != '-' {
+		return SpanContext{}, false
+	}
+	if version[0] == 0 && len(value) != 55 {
+		return SpanContext{}, false
+	}
+	if len(value) > 55 && value[55] != '-' {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, false
+	}
+	if !decodeLowerHex(sc.TraceID[:], value[3:35]) || !decodeLowerHex(sc.SpanID[:], value[36:52]) {
+		return SpanContext{}, false
+	}
+
+	var flags [1]byte
+	if !decodeLowerHex(flags[:], value[53:55]) {
+		return SpanContext{}, false
+	}
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+
+	return sc, true
+}
+
+func decodeLowerHex(dst []byte, src string) bool {
+	if strings.ToLower(src) != src {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+// Extract возвращает контекст с удалённым родительским спаном из заголовков
+// запроса. Если traceparent отсутствует или некорректен, контекст не меняется.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get(traceparentHeader))
+	if !ok {
+		return ctx
+	}
+
+	sc.TraceState = header.Get(tracestateHeader)
+	sc.Remote = true
+
+	return ContextWithSpan(ctx, &Span{spanContext: sc})
+}
+
+// Inject записывает контекст текущего спана в заголовки исходящего запроса.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+
+	header.Set(traceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(tracestateHeader, sc.TraceState)
+	} else {
+		header.Del(tracestateHeader)
+	}
+}
+
+type spanKey struct{}
+
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext возвращает текущий спан или nil. Методы Span безопасно
+// вызывать и для nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
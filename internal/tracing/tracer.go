@@ -0,0 +1,229 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+type SpanKind int
+
+// Значения совпадают с SpanKind в OTLP.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Tracer создаёт спаны и передаёт завершённые сэмплированные спаны
+// экспортёру. Tracer без экспортёра работает в режиме no-op: спаны не
+// записываются, но контекст трассировки передаётся дальше.
+type Tracer struct {
+	exporter    *BatchExporter
+	sampleRatio float64
+}
+
+func NewTracer(cfg config.TracingConfig) *Tracer {
+	if !cfg.Enabled {
+		return NewNoopTracer()
+	}
+
+	return &Tracer{
+		exporter:    NewBatchExporter(NewOTLPExporter(cfg), cfg.BatchSize, cfg.FlushInterval),
+		sampleRatio: cfg.SampleRatio,
+	}
+}
+
+func NewNoopTracer() *Tracer {
+	return &Tracer{}
+}
+
+func (t *Tracer) Enabled() bool {
+	return t.exporter != nil
+}
+
+// Start создаёт дочерний спан текущего спана из ctx. Если родителя нет,
+// решение о сэмплировании принимается по доле sample_ratio, иначе
+// наследуется от родителя.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if !t.Enabled() {
+		// В режиме no-op входящий контекст передаётся дальше без изменений.
+		return ctx, SpanFromContext(ctx)
+	}
+
+	parent := SpanFromContext(ctx).SpanContext()
+
+	sc := SpanContext{
+		TraceID:    parent.TraceID,
+		Flags:      parent.Flags,
+		TraceState: parent.TraceState,
+	}
+	if !parent.IsValid() {
+		sc.TraceID = newTraceID()
+		sc.Flags = 0
+		if t.sample(sc.TraceID) {
+			sc.Flags |= flagSampled
+		}
+	}
+	sc.SpanID = newSpanID()
+
+	span := &Span{
+		spanContext: sc,
+		parentID:    parent.SpanID,
+		name:        name,
+		kind:        kind,
+		start:       time.Now(),
+	}
+	if sc.IsSampled() {
+		span.exporter = t.exporter
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample реализует TraceIDRatioBased из OpenTelemetry: решение зависит только
+// от идентификатора трассы, поэтому одинаково во всех сервисах.
+func (t *Tracer) sample(traceID TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	if t.sampleRatio <= 0 {
+		return false
+	}
+
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+}
+
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+type Span struct {
+	spanContext SpanContext
+	parentID    SpanID
+	name        string
+	kind        SpanKind
+	start       time.Time
+	exporter    *BatchExporter
+
+	mu            sync.Mutex
+	end           time.Time
+	attributes    []Attribute
+	status        StatusCode
+	statusMessage string
+	ended         bool
+}
+
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// IsRecording сообщает, будет ли спан экспортирован. Для остальных спанов
+// можно не вычислять атрибуты.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.exporter != nil
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attributes = append(s.attributes, attrs...)
+}
+
+func (s *Span) SetError(err error) {
+	if !s.IsRecording() || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = StatusError
+	s.statusMessage = err.Error()
+}
+
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = code
+	s.statusMessage = message
+}
+
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.exporter.enqueue(s)
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,224 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantOK  bool
+		sampled bool
+	}{
+		{name: "Sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantOK: true, sampled: true},
+		{name: "Not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", wantOK: true},
+		{name: "Future version with extra fields", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantOK: true, sampled: true},
+		{name: "Version 00 with extra fields", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "Invalid version ff", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "Zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "Zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "Uppercase hex", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "Too short", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7"},
+		{name: "Empty", value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseTraceparent() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if sc.IsSampled() != tt.sampled {
+				t.Errorf("ParseTraceparent() sampled = %v, want %v", sc.IsSampled(), tt.sampled)
+			}
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("ParseTraceparent() trace id = %s", sc.TraceID)
+			}
+		})
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	in := http.Header{}
+	in.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	in.Set("tracestate", "vendor=value")
+
+	ctx := Extract(context.Background(), in)
+
+	out := http.Header{}
+	Inject(ctx, out)
+
+	if out.Get("traceparent") != in.Get("traceparent") || out.Get("tracestate") != "vendor=value" {
+		t.Errorf("Inject() headers = %v, want propagated incoming context", out)
+	}
+
+	empty := http.Header{}
+	Inject(context.Background(), empty)
+	if len(empty) != 0 {
+		t.Errorf("Inject() without span should not set headers, got %v", empty)
+	}
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	tracer := NewTracer(testConfig("http://127.0.0.1:0", 0))
+	defer tracer.Shutdown(context.Background())
+
+	_, root := tracer.Start(context.Background(), "root", KindServer)
+	if root.IsRecording() || root.SpanContext().IsSampled() {
+		t.Errorf("root span should not be sampled with sample_ratio 0")
+	}
+
+	parent := http.Header{}
+	parent.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, child := tracer.Start(Extract(context.Background(), parent), "child", KindServer)
+	if !child.IsRecording() {
+		t.Errorf("span with sampled remote parent should be recorded")
+	}
+	if child.SpanContext().TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("child span should continue remote trace")
+	}
+}
+
+func TestNoopTracer(t *testing.T) {
+	tracer := NewNoopTracer()
+
+	in := http.Header{}
+	in.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, span := tracer.Start(Extract(context.Background(), in), "request", KindServer)
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("ignored"))
+	span.End()
+
+	if span.IsRecording() {
+		t.Errorf("no-op tracer should not record spans")
+	}
+
+	out := http.Header{}
+	Inject(ctx, out)
+	if out.Get("traceparent") != in.Get("traceparent") {
+		t.Errorf("no-op tracer should propagate incoming context unchanged, got %q", out.Get("traceparent"))
+	}
+}
+
+func TestOTLPExport(t *testing.T) {
+	collector := newCollectorStub(t)
+
+	tracer := NewTracer(testConfig(collector.URL(), 1))
+
+	ctx, root := tracer.Start(context.Background(), "HTTP GET", KindServer)
+	root.SetAttributes(String("url.path", "/api"), Int("http.response.status_code", 200), Bool("cached", false))
+
+	_, child := tracer.Start(ctx, "balancer.select", KindInternal)
+	child.SetError(errors.New("no backends"))
+	child.End()
+	root.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	spans := collector.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("collector received %d spans, want 2", len(spans))
+	}
+	if collector.contentType != "application/json" {
+		t.Errorf("export Content-Type = %s, want application/json", collector.contentType)
+	}
+	if collector.serviceName != "test-service" {
+		t.Errorf("resource service.name = %s, want test-service", collector.serviceName)
+	}
+
+	byName := make(map[string]otlpSpan)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	exportedRoot, exportedChild := byName["HTTP GET"], byName["balancer.select"]
+	if exportedChild.ParentSpanID != exportedRoot.SpanID || exportedChild.TraceID != exportedRoot.TraceID {
+		t.Errorf("child span should reference root span as parent")
+	}
+	if exportedRoot.ParentSpanID != "" || exportedRoot.Kind != KindServer {
+		t.Errorf("root span = %+v, want server span without parent", exportedRoot)
+	}
+	if exportedChild.Status.Code != StatusError || exportedChild.Status.Message != "no backends" {
+		t.Errorf("child span status = %+v, want error", exportedChild.Status)
+	}
+	if len(exportedRoot.Attributes) != 3 || *exportedRoot.Attributes[1].Value.IntValue != "200" {
+		t.Errorf("root span attributes = %+v", exportedRoot.Attributes)
+	}
+}
+
+func testConfig(endpoint string, ratio float64) config.TracingConfig {
+	return config.TracingConfig{
+		Enabled:       true,
+		Endpoint:      endpoint,
+		ServiceName:   "test-service",
+		SampleRatio:   ratio,
+		BatchSize:     16,
+		FlushInterval: time.Hour,
+		Timeout:       time.Second,
+	}
+}
+
+type collectorStub struct {
+	server      *httptest.Server
+	mu          sync.Mutex
+	spans       []otlpSpan
+	contentType string
+	serviceName string
+}
+
+func newCollectorStub(t *testing.T) *collectorStub {
+	c := &collectorStub{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("collector failed to decode request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.contentType = r.Header.Get("Content-Type")
+		for _, rs := range req.ResourceSpans {
+			for _, attr := range rs.Resource.Attributes {
+				if attr.Key == "service.name" && attr.Value.StringValue != nil {
+					c.serviceName = *attr.Value.StringValue
+				}
+			}
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(c.server.Close)
+
+	return c
+}
+
+func (c *collectorStub) URL() string {
+	return c.server.URL + "/v1/traces"
+}
+
+func (c *collectorStub) Spans() []otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]otlpSpan(nil), c.spans...)
+}
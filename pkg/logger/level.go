@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Подсистемы, уровень логирования которых можно менять отдельно от общего.
+const (
+	Balancer  = "balancer"
+	Health    = "health"
+	Proxy     = "proxy"
+	RateLimit = "ratelimit"
+	Storage   = "storage"
+)
+
+var Components = []string{Balancer, Health, Proxy, RateLimit, Storage}
+
+// levelSetting - настроенный уровень. Временное значение через revertAfter
+// откатывается к последнему постоянному.
+type levelSetting struct {
+	level      zerolog.Level
+	persistent zerolog.Level
+	revertAt   time.Time
+	timer      *time.Timer
+}
+
+var levels = struct {
+	mu         sync.Mutex
+	base       levelSetting
+	components map[string]*levelSetting
+
+	// Действующие уровни подсистем читаются на каждой записи, поэтому
+	// хранятся отдельно в атомиках. Набор ключей фиксирован и не меняется.
+	effective map[string]*atomic.Int32
+}{
+	base:       levelSetting{level: zerolog.InfoLevel, persistent: zerolog.InfoLevel},
+	components: make(map[string]*levelSetting),
+	effective:  make(map[string]*atomic.Int32),
+}
+
+func init() {
+	for _, name := range Components {
+		levels.components[name] = &levelSetting{level: zerolog.NoLevel, persistent: zerolog.NoLevel}
+		levels.effective[name] = new(atomic.Int32)
+		levels.effective[name].Store(int32(zerolog.InfoLevel))
+	}
+}
+
+// SetLevel меняет общий уровень логирования. При revertAfter > 0 уровень
+// вернётся к предыдущему постоянному значению через заданное время.
+func SetLevel(level zerolog.Level, revertAfter time.Duration) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	set(&levels.base, level, revertAfter, "")
+	applyLevels()
+}
+
+// SetComponentLevel меняет уровень подсистемы. zerolog.NoLevel сбрасывает
+// уровень подсистемы, и она снова следует общему.
+func SetComponentLevel(component string, level zerolog.Level, revertAfter time.Duration) error {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	setting, ok := levels.components[component]
+	if !ok {
+		return fmt.Errorf("unknown log component: %s", component)
+	}
+
+	set(setting, level, revertAfter, component)
+	applyLevels()
+
+	return nil
+}
+
+func set(setting *levelSetting, level zerolog.Level, revertAfter time.Duration, component string) {
+	if setting.timer != nil {
+		setting.timer.Stop()
+		setting.timer = nil
+	}
+	setting.revertAt = time.Time{}
+	setting.level = level
+
+	if revertAfter <= 0 {
+		setting.persistent = level
+		return
+	}
+
+	setting.revertAt = time.Now().Add(revertAfter)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(revertAfter, func() {
+		levels.mu.Lock()
+		defer levels.mu.Unlock()
+
+		// Таймер мог быть заменён новым изменением уровня.
+		if setting.timer != timer {
+			return
+		}
+		setting.timer = nil
+		setting.revertAt = time.Time{}
+		setting.level = setting.persistent
+		applyLevels()
+
+		event := log.Info().Str("level", levelName(setting.level))
+		if component != "" {
+			event = event.Str("log_component", component)
+		}
+		event.Msg("Log level reverted")
+	})
+	setting.timer = timer
+}
+
+// applyLevels пересчитывает действующие уровни. Глобальный уровень zerolog
+// равен общему, поэтому записи ниже него без подсистемы отбрасываются до
+// сериализации; уровни подсистем проверяет Logger.
+func applyLevels() {
+	base := levels.base.level
+
+	for name, setting := range levels.components {
+		level := setting.level
+		if level == zerolog.NoLevel {
+			level = base
+		}
+		levels.effective[name].Store(int32(level))
+	}
+
+	zerolog.SetGlobalLevel(base)
+}
+
+type LevelStatus struct {
+	Level      string                    `json:"level"`
+	RevertAt   *time.Time                `json:"revert_at,omitempty"`
+	Components map[string]ComponentLevel `json:"components"`
+}
+
+type ComponentLevel struct {
+	Level     string     `json:"level"`
+	Inherited bool       `json:"inherited"`
+	RevertAt  *time.Time `json:"revert_at,omitempty"`
+}
+
+func Levels() LevelStatus {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	status := LevelStatus{
+		Level:      levelName(levels.base.level),
+		RevertAt:   revertAt(&levels.base),
+		Components: make(map[string]ComponentLevel, len(levels.components)),
+	}
+
+	for name, setting := range levels.components {
+		status.Components[name] = ComponentLevel{
+			Level:     levelName(zerolog.Level(levels.effective[name].Load())),
+			Inherited: setting.level == zerolog.NoLevel,
+			RevertAt:  revertAt(setting),
+		}
+	}
+
+	return status
+}
+
+func revertAt(setting *levelSetting) *time.Time {
+	if setting.revertAt.IsZero() {
+		return nil
+	}
+	t := setting.revertAt
+	return &t
+}
+
+func levelName(level zerolog.Level) string {
+	if level == zerolog.NoLevel {
+		return ""
+	}
+	return level.String()
+}
+
+// Logger пишет записи подсистемы с полем component, проверяя её уровень.
+// Записи идут через глобальный логгер или логгер из контекста запроса.
+type Logger struct {
+	component string
+	level     *atomic.Int32
+	ctx       context.Context
+}
+
+// For возвращает логгер подсистемы. Имя должно быть из Components.
+func For(component string) Logger {
+	level, ok := levels.effective[component]
+	if !ok {
+		panic("unknown log component: " + component)
+	}
+	return Logger{component: component, level: level}
+}
+
+// Ctx возвращает логгер, пишущий через логгер из контекста (log.Ctx), чтобы
+// в записи попали поля запроса вроде request_id.
+func (l Logger) Ctx(ctx context.Context) Logger {
+	l.ctx = ctx
+	return l
+}
+
+func (l Logger) Trace() *zerolog.Event { return l.event(zerolog.TraceLevel) }
+func (l Logger) Debug() *zerolog.Event { return l.event(zerolog.DebugLevel) }
+func (l Logger) Info() *zerolog.Event  { return l.event(zerolog.InfoLevel) }
+func (l Logger) Warn() *zerolog.Event  { return l.event(zerolog.WarnLevel) }
+func (l Logger) Error() *zerolog.Event { return l.event(zerolog.ErrorLevel) }
+
+func (l Logger) event(level zerolog.Level) *zerolog.Event {
+	if level < zerolog.Level(l.level.Load()) {
+		return nil
+	}
+
+	logger := &log.Logger
+	if l.ctx != nil {
+		logger = log.Ctx(l.ctx)
+	}
+
+	if level >= zerolog.GlobalLevel() {
+		return logger.WithLevel(level).Str("component", l.component)
+	}
+
+	// Уровень подсистемы подробнее общего: глобальный уровень zerolog
+	// отбросил бы запись, поэтому она пишется без уровня, а поле level
+	// добавляется вручную.
+	return logger.Log().
+		Str(zerolog.LevelFieldName, zerolog.LevelFieldMarshalFunc(level)).
+		Str("component", l.component)
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+
+	t.Cleanup(func() {
+		log.Logger = previous
+		SetLevel(zerolog.InfoLevel, 0)
+		for _, name := range Components {
+			SetComponentLevel(name, zerolog.NoLevel, 0)
+		}
+	})
+
+	return &buf
+}
+
+func TestComponentLevel(t *testing.T) {
+	buf := captureLogs(t)
+
+	SetLevel(zerolog.InfoLevel, 0)
+	if err := SetComponentLevel(Balancer, zerolog.DebugLevel, 0); err != nil {
+		t.Fatalf("SetComponentLevel failed: %v", err)
+	}
+
+	For(Balancer).Debug().Msg("balancer debug")
+	For(Health).Debug().Msg("health debug")
+	log.Debug().Msg("global debug")
+	log.Info().Msg("global info")
+
+	out := buf.String()
+	if !strings.Contains(out, "balancer debug") || !strings.Contains(out, `"component":"balancer"`) {
+		t.Errorf("Expected balancer debug record, got %q", out)
+	}
+	if strings.Contains(out, "health debug") {
+		t.Errorf("Expected health debug to follow global level, got %q", out)
+	}
+	if strings.Contains(out, "global debug") {
+		t.Errorf("Expected global debug to be filtered, got %q", out)
+	}
+	if !strings.Contains(out, "global info") {
+		t.Errorf("Expected global info record, got %q", out)
+	}
+
+	status := Levels()
+	if status.Components[Balancer].Level != "debug" || status.Components[Balancer].Inherited {
+		t.Errorf("Unexpected balancer status: %+v", status.Components[Balancer])
+	}
+	if status.Components[Health].Level != "info" || !status.Components[Health].Inherited {
+		t.Errorf("Unexpected health status: %+v", status.Components[Health])
+	}
+}
+
+func TestComponentLevel_GlobalStaysBase(t *testing.T) {
+	buf := captureLogs(t)
+
+	SetLevel(zerolog.InfoLevel, 0)
+	SetComponentLevel(Storage, zerolog.DebugLevel, 0)
+
+	// Подробный уровень подсистемы не включает отладочные записи остальных:
+	// они отбрасываются до сериализации, а не после.
+	if zerolog.GlobalLevel() != zerolog.InfoLevel {
+		t.Errorf("Expected global level info, got %s", zerolog.GlobalLevel())
+	}
+	if log.Debug().Enabled() {
+		t.Error("Expected global debug event to be disabled")
+	}
+
+	log.Debug().Str("note", `"component":"storage"`).Msg("spoofed component")
+	For(Storage).Debug().Msg("storage debug")
+
+	out := buf.String()
+	if strings.Contains(out, "spoofed component") {
+		t.Errorf("Expected record mentioning component to be filtered, got %q", out)
+	}
+	if !strings.Contains(out, `"level":"debug"`) || !strings.Contains(out, "storage debug") {
+		t.Errorf("Expected storage debug record with level field, got %q", out)
+	}
+}
+
+func TestComponentLevel_Quieter(t *testing.T) {
+	buf := captureLogs(t)
+
+	SetLevel(zerolog.DebugLevel, 0)
+	SetComponentLevel(Proxy, zerolog.ErrorLevel, 0)
+
+	For(Proxy).Warn().Msg("proxy warn")
+	For(RateLimit).Debug().Msg("ratelimit debug")
+
+	out := buf.String()
+	if strings.Contains(out, "proxy warn") {
+		t.Errorf("Expected proxy warn to be filtered, got %q", out)
+	}
+	if !strings.Contains(out, "ratelimit debug") {
+		t.Errorf("Expected ratelimit debug record, got %q", out)
+	}
+}
+
+func TestSetComponentLevel_Unknown(t *testing.T) {
+	if err := SetComponentLevel("unknown", zerolog.DebugLevel, 0); err == nil {
+		t.Error("Expected error for unknown component")
+	}
+}
+
+func TestLevel_Revert(t *testing.T) {
+	captureLogs(t)
+
+	SetLevel(zerolog.WarnLevel, 0)
+	SetLevel(zerolog.DebugLevel, 50*time.Millisecond)
+	SetComponentLevel(Storage, zerolog.TraceLevel, 50*time.Millisecond)
+
+	status := Levels()
+	if status.Level != "debug" || status.RevertAt == nil {
+		t.Fatalf("Expected temporary debug level, got %+v", status)
+	}
+	if zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Errorf("Expected global level debug, got %s", zerolog.GlobalLevel())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		status = Levels()
+		if status.Level == "warn" && status.Components[Storage].Inherited {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status.Level != "warn" || status.RevertAt != nil {
+		t.Errorf("Expected level to revert to warn, got %+v", status)
+	}
+	if !status.Components[Storage].Inherited {
+		t.Errorf("Expected storage level to revert to inherited, got %+v", status.Components[Storage])
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Errorf("Expected global level warn, got %s", zerolog.GlobalLevel())
+	}
+}
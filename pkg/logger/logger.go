@@ -1,29 +1,42 @@
 package logger
 
 import (
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
-	"go-cloud-camp-2025-test-assignment/config"
+	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
-func Setup(cfg config.LoggerConfig) {
+var (
+	fileMu sync.Mutex
+	file   *RotatingFile
+)
+
+// Setup настраивает глобальный логгер. Если журнал пишется в файл и его не
+// удалось открыть, возвращается ошибка: молча переключаться на stdout нельзя.
+func Setup(cfg config.LoggerConfig) error {
 	level, err := zerolog.ParseLevel(strings.ToLower(cfg.Level))
 	if err != nil {
 		level = zerolog.DebugLevel
 	}
-	zerolog.SetGlobalLevel(level)
+	SetLevel(level, 0)
 
 	var output io.Writer = os.Stdout
 
-	if cfg.Output == "file" && cfg.FilePath != "" {
-		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-		if err == nil {
-			output = file
+	var rotating *RotatingFile
+	if strings.ToLower(cfg.Output) == "file" {
+		rotating, err = NewRotatingFile(cfg.FilePath, cfg.Rotation)
+		if err != nil {
+			return fmt.Errorf("failed to set up log file %s: %w", cfg.FilePath, err)
 		}
+		output = rotating
 	}
 
 	var writer = output
@@ -39,9 +52,47 @@ func Setup(cfg config.LoggerConfig) {
 
 	log.Logger = logger.Logger()
 
+	fileMu.Lock()
+	previous := file
+	file = rotating
+	fileMu.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+
+	// Логгер запроса из контекста (log.Ctx) по умолчанию совпадает с глобальным.
+	zerolog.DefaultContextLogger = &log.Logger
+
 	log.Info().
 		Str("level", level.String()).
 		Str("format", cfg.Format).
 		Str("output", cfg.Output).
 		Msg("Logger initialized")
+
+	return nil
+}
+
+// Reopen заново открывает файл журнала после внешней ротации. Если журнал
+// пишется не в файл, ничего не делает.
+func Reopen() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	return file.Reopen()
+}
+
+// Close закрывает файл журнала и дожидается фонового сжатия ротированных файлов.
+func Close() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	return err
 }
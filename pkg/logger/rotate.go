@@ -0,0 +1,316 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFile - файл журнала с ротацией по размеру и по времени. Старые
+// файлы переименовываются в <имя>-<время><расширение>, при необходимости
+// сжимаются gzip и удаляются сверх max_backups. Если за одну миллисекунду
+// ротация происходит повторно, к времени добавляется номер: <время>-1, <время>-2.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	compress   bool
+
+	mu       sync.Mutex
+	file     *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+
+	// Сжатие и удаление старых файлов выполняются в фоне по одному.
+	millMu sync.Mutex
+	millWg sync.WaitGroup
+
+	now func() time.Time
+}
+
+func NewRotatingFile(path string, cfg config.RotationConfig) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		interval:   cfg.Interval,
+		maxBackups: cfg.MaxBackups,
+		compress:   cfg.Compress,
+		now:        time.Now,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if dir := filepath.Dir(f.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	// После неудачной ротации или Reopen файл пробуем открыть снова.
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(next int64) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+next > f.maxSize {
+		return true
+	}
+	if f.interval > 0 && f.now().Sub(f.openedAt) >= f.interval {
+		return true
+	}
+	return false
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+
+	backup := f.backupName(f.now())
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.millWg.Add(1)
+	go func() {
+		defer f.millWg.Done()
+		f.mill(backup)
+	}()
+
+	return nil
+}
+
+// Reopen закрывает и заново открывает файл по тому же пути. Используется,
+// когда файл переименован внешней утилитой вроде logrotate.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+
+	return f.open()
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	f.closed = true
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.millWg.Wait()
+
+	return err
+}
+
+// backupName возвращает свободное имя для ротированного файла. Занятым
+// считается и имя, под которым уже лежит сжатая копия.
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, base := filepath.Split(f.path)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(base, ext) + "-" + t.Format(backupTimeFormat)
+
+	for seq := 0; ; seq++ {
+		name := stamp
+		if seq > 0 {
+			name += "-" + strconv.Itoa(seq)
+		}
+		backup := filepath.Join(dir, name+ext)
+		if !fileExists(backup) && !fileExists(backup+".gz") {
+			return backup
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func (f *RotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: failed to compress %s: %v\n", backup, err)
+		}
+	}
+
+	if f.maxBackups > 0 {
+		backups, err := f.backups()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logger: failed to list log backups: %v\n", err)
+			return
+		}
+		for _, old := range backups[:max(len(backups)-f.maxBackups, 0)] {
+			if err := os.Remove(old); err != nil {
+				fmt.Fprintf(os.Stderr, "logger: failed to remove %s: %v\n", old, err)
+			}
+		}
+	}
+}
+
+// backups возвращает ротированные файлы от старых к новым.
+func (f *RotatingFile) backups() ([]string, error) {
+	dir, base := filepath.Split(f.path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		name string
+		time time.Time
+		seq  int
+	}
+
+	var found []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+
+		stamp, suffix, hasSeq := strings.Cut(stamp, "-")
+		seq := 0
+		if hasSeq {
+			n, err := strconv.Atoi(suffix)
+			if err != nil || n <= 0 {
+				continue
+			}
+			seq = n
+		}
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		found = append(found, backup{name: name, time: t, seq: seq})
+	}
+
+	// Номер после времени сравнивается как число: <время>-10 новее <время>-9.
+	slices.SortFunc(found, func(a, b backup) int {
+		if c := a.time.Compare(b.time); c != 0 {
+			return c
+		}
+		return a.seq - b.seq
+	})
+
+	backups := make([]string, 0, len(found))
+	for _, b := range found {
+		backups = append(backups, filepath.Join(dir, b.name))
+	}
+
+	return backups, nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	return os.Remove(path)
+}
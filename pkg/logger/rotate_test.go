@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cloud-camp-2025-test-assignment/config"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFile_Size(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := NewRotatingFile(path, config.RotationConfig{MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("Failed to open rotating file: %v", err)
+	}
+
+	line := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < 1024; i++ {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if _, err := f.Write([]byte("next\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	f.Close()
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %d", len(backups))
+	}
+	if info, _ := os.Stat(backups[0]); info.Size() != 1024*1024 {
+		t.Errorf("Expected backup of 1MiB, got %d bytes", info.Size())
+	}
+	if got := readFile(t, path); got != "next\n" {
+		t.Errorf("Expected current file to contain only the last write, got %q", got)
+	}
+}
+
+func TestRotatingFile_IntervalAndRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	f := &RotatingFile{
+		path:       path,
+		interval:   time.Hour,
+		maxBackups: 2,
+		compress:   true,
+		now:        func() time.Time { return now },
+	}
+	if err := f.open(); err != nil {
+		t.Fatalf("Failed to open rotating file: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		f.Write([]byte("entry\n"))
+		now = now.Add(time.Hour)
+	}
+	f.Write([]byte("last\n"))
+	f.Close()
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups after retention, got %d: %v", len(backups), backups)
+	}
+	if !strings.HasSuffix(backups[1], "app-20250101T040000.000.log.gz") {
+		t.Errorf("Unexpected newest backup name: %s", backups[1])
+	}
+
+	file, err := os.Open(backups[1])
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Backup is not gzip: %v", err)
+	}
+	data, _ := io.ReadAll(gz)
+	if string(data) != "entry\n" {
+		t.Errorf("Expected backup to contain one entry, got %q", data)
+	}
+
+	if got := readFile(t, path); got != "last\n" {
+		t.Errorf("Expected current file to contain the last write, got %q", got)
+	}
+}
+
+func TestRotatingFile_SameMillisecond(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	f := &RotatingFile{
+		path:    path,
+		maxSize: 1,
+		now:     func() time.Time { return now },
+	}
+	if err := f.open(); err != nil {
+		t.Fatalf("Failed to open rotating file: %v", err)
+	}
+
+	// Каждая запись, кроме первой, вызывает ротацию в ту же миллисекунду.
+	for i := 0; i < 12; i++ {
+		if _, err := f.Write([]byte(fmt.Sprintf("entry %d\n", i))); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	f.Close()
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 11 {
+		t.Fatalf("Expected 11 backups, got %d: %v", len(backups), backups)
+	}
+	if !strings.HasSuffix(backups[0], "app-20250101T000000.000.log") {
+		t.Errorf("Unexpected first backup name: %s", backups[0])
+	}
+	if !strings.HasSuffix(backups[10], "app-20250101T000000.000-10.log") {
+		t.Errorf("Unexpected newest backup name: %s", backups[10])
+	}
+
+	for i, backup := range backups {
+		if got, want := readFile(t, backup), fmt.Sprintf("entry %d\n", i); got != want {
+			t.Errorf("Backup %s: expected %q, got %q", backup, want, got)
+		}
+	}
+	if got := readFile(t, path); got != "entry 11\n" {
+		t.Errorf("Expected current file to contain the last write, got %q", got)
+	}
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(path, config.RotationConfig{})
+	if err != nil {
+		t.Fatalf("Failed to open rotating file: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("before\n"))
+
+	// Так файл переименовывает logrotate.
+	moved := filepath.Join(dir, "app.log.1")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatalf("Failed to rename log: %v", err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	f.Write([]byte("after\n"))
+
+	if got := readFile(t, moved); got != "before\n" {
+		t.Errorf("Expected moved file to keep old entries, got %q", got)
+	}
+	if got := readFile(t, path); got != "after\n" {
+		t.Errorf("Expected new file to receive new entries, got %q", got)
+	}
+}
+
+func TestSetup_FileOpenError(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	err := Setup(config.LoggerConfig{
+		Level:    "info",
+		Format:   "json",
+		Output:   "file",
+		FilePath: filepath.Join(blocker, "app.log"),
+	})
+	if err == nil {
+		t.Fatal("Expected error when log file can't be opened")
+	}
+}